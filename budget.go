@@ -0,0 +1,161 @@
+package biloba
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// budgetContributorsCount caps the breakdowns printed when a budget is exceeded.
+const budgetContributorsCount = 5
+
+type timeBudgetReporter struct {
+	t               ginkgo.GinkgoTestingT
+	budget          time.Duration
+	describeBudgets map[string]time.Duration
+	out             io.Writer
+
+	suite    string
+	testFunc string
+	// containers sums up the time spent in each top-level container, in the
+	// order they first ran, and in BeforeSuite and AfterSuite
+	containers     map[string]time.Duration
+	containerOrder []string
+	specs          []slowSpec
+	specContainers []string
+}
+
+// NewTimeBudgetReporter fails t, usually the *testing.T passed to RunSpecs,
+// when the suite takes longer than budget, printing the top-level containers
+// and specs that took the most time. A budget of 0 only enforces the budgets
+// of DescribeBudget.
+func NewTimeBudgetReporter(t ginkgo.GinkgoTestingT, budget time.Duration) *timeBudgetReporter {
+	return &timeBudgetReporter{
+		t:               t,
+		budget:          budget,
+		describeBudgets: map[string]time.Duration{},
+		out:             os.Stdout,
+	}
+}
+
+// DescribeBudget also fails the suite when the specs of the top-level
+// container with the given text, retries included, take longer than budget.
+func (r *timeBudgetReporter) DescribeBudget(text string, budget time.Duration) *timeBudgetReporter {
+	r.describeBudgets[text] = budget
+	return r
+}
+
+func (r *timeBudgetReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.suite = summary.SuiteDescription
+	r.testFunc = suiteTestFunc()
+	r.containers = map[string]time.Duration{}
+	r.containerOrder = nil
+	r.specs = nil
+	r.specContainers = nil
+}
+
+func (r *timeBudgetReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.add("BeforeSuite", setupSummary.RunTime)
+}
+
+func (r *timeBudgetReporter) SpecWillRun(specSummary *types.SpecSummary) {}
+
+func (r *timeBudgetReporter) SpecDidComplete(spec *types.SpecSummary) {
+	container := spec.ComponentTexts[1]
+	r.add(container, spec.RunTime)
+	r.specs = append(r.specs, slowSpec{name: testName(spec), runTime: spec.RunTime, location: specLocation(spec)})
+	r.specContainers = append(r.specContainers, container)
+}
+
+func (r *timeBudgetReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+	r.add("AfterSuite", setupSummary.RunTime)
+}
+
+func (r *timeBudgetReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	suiteExceeded := r.budget > 0 && summary.RunTime > r.budget
+	var exceededContainers []string
+	for _, container := range r.containerOrder {
+		if budget, ok := r.describeBudgets[container]; ok && r.containers[container] > budget {
+			exceededContainers = append(exceededContainers, container)
+		}
+	}
+	if !suiteExceeded && len(exceededContainers) == 0 {
+		return
+	}
+
+	continueTestFunc(r.out, r.testFunc)
+	if suiteExceeded {
+		fmt.Fprintf(r.out, "\nTIME BUDGET EXCEEDED: %s took %.3fs, over its budget of %.3fs\n", r.suite, summary.RunTime.Seconds(), r.budget.Seconds())
+		r.printContainers(summary.RunTime)
+		r.printSpecs("")
+	}
+	for _, container := range exceededContainers {
+		fmt.Fprintf(r.out, "\nTIME BUDGET EXCEEDED: %s took %.3fs, over its budget of %.3fs\n", container, r.containers[container].Seconds(), r.describeBudgets[container].Seconds())
+		r.printSpecs(container)
+	}
+	r.t.Fail()
+}
+
+func (r *timeBudgetReporter) add(container string, runTime time.Duration) {
+	if _, ok := r.containers[container]; !ok {
+		r.containerOrder = append(r.containerOrder, container)
+	}
+	r.containers[container] += runTime
+}
+
+// printContainers prints the top-level containers that took the most time,
+// with their share of the suite's total.
+func (r *timeBudgetReporter) printContainers(total time.Duration) {
+	containers := append([]string(nil), r.containerOrder...)
+	sort.SliceStable(containers, func(i, j int) bool {
+		return r.containers[containers[i]] > r.containers[containers[j]]
+	})
+	if len(containers) > budgetContributorsCount {
+		containers = containers[:budgetContributorsCount]
+	}
+
+	fmt.Fprintf(r.out, "Largest contributors:\n")
+	table := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, container := range containers {
+		runTime := r.containers[container]
+		fmt.Fprintf(table, "    %.3fs\t%.0f%%\t%s\n", runTime.Seconds(), 100*runTime.Seconds()/total.Seconds(), container)
+	}
+	table.Flush()
+}
+
+// printSpecs prints the specs that took the most time, only those of the
+// given top-level container unless it is empty.
+func (r *timeBudgetReporter) printSpecs(container string) {
+	var specs []slowSpec
+	for i, spec := range r.specs {
+		if container == "" || r.specContainers[i] == container {
+			specs = append(specs, spec)
+		}
+	}
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].runTime > specs[j].runTime
+	})
+	if len(specs) > budgetContributorsCount {
+		specs = specs[:budgetContributorsCount]
+	}
+	if len(specs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(r.out, "Slowest specs:\n")
+	table := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, spec := range specs {
+		fmt.Fprintf(table, "    %.3fs\t%s\t%s\n", spec.runTime.Seconds(), spec.name, spec.location)
+	}
+	table.Flush()
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(timeBudgetReporter)
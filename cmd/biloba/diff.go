@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/matt-royal/biloba"
+)
+
+// diff runs "biloba diff [flags] <old report> <new report>".
+func diff(args []string) error {
+	flags := flag.NewFlagSet("biloba diff", flag.ContinueOnError)
+	ratio := flags.Float64("ratio", 1.5, "list specs as slower when they took more than `ratio` times as long, 0 to only use -threshold")
+	threshold := flags.Duration("threshold", 100*time.Millisecond, "list specs as slower when they took more than `duration` longer")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: biloba diff [flags] <old report> <new report>\n\n")
+		fmt.Fprintf(flags.Output(), "The reports are JSON reports written by biloba.NewJSONReporter or JUnit XML files.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("expected an old and a new report")
+	}
+
+	old, err := readReport(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := readReport(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	reportDiff := biloba.DiffReports(old, new, *ratio, *threshold)
+	if reportDiff.Empty() {
+		fmt.Println("No differences.")
+		return nil
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	printSpecs(out, "Newly failing", reportDiff.NewlyFailing)
+	printSpecs(out, "Newly passing", reportDiff.NewlyPassing)
+	printSpecs(out, "Added", reportDiff.Added)
+	printSpecs(out, "Removed", reportDiff.Removed)
+	if len(reportDiff.Renamed) > 0 {
+		fmt.Fprintf(out, "Renamed (%d):\n", len(reportDiff.Renamed))
+		for _, rename := range reportDiff.Renamed {
+			fmt.Fprintf(out, "    %s\t-> %s\t%s\n", rename.Old.Name, rename.New.Name, rename.New.Location)
+		}
+		fmt.Fprintln(out)
+	}
+	if len(reportDiff.Slower) > 0 {
+		fmt.Fprintf(out, "Slower (%d):\n", len(reportDiff.Slower))
+		for _, slower := range reportDiff.Slower {
+			fmt.Fprintf(out, "    %s\twas %s\t%.2fx\t%s\t%s\n", formatSeconds(slower.RunTime), formatSeconds(slower.Baseline), slower.Ratio(), slower.Name, slower.Location)
+		}
+		fmt.Fprintln(out)
+	}
+	return out.Flush()
+}
+
+func printSpecs(out *tabwriter.Writer, title string, specs []biloba.SpecReport) {
+	if len(specs) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s (%d):\n", title, len(specs))
+	for _, spec := range specs {
+		if spec.Location == "" {
+			fmt.Fprintf(out, "    %s\t%s\n", spec.State, spec.Name)
+			continue
+		}
+		fmt.Fprintf(out, "    %s\t%s\t%s\n", spec.State, spec.Name, spec.Location)
+	}
+	fmt.Fprintln(out)
+}
+
+// readReport reads a biloba JSON report, or a JUnit file when it starts with
+// an XML element.
+func readReport(filename string) (biloba.Report, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return biloba.Report{}, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return biloba.ReadJUnitReport(filename)
+	}
+	return biloba.ReadReport(filename)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/matt-royal/biloba"
+)
+
+// history runs "biloba history [flags] <flaky|durations|new-failures>".
+func history(args []string) error {
+	flags := flag.NewFlagSet("biloba history", flag.ContinueOnError)
+	dir := flags.String("dir", biloba.DefaultHistoryDir, "read the history recorded by biloba.NewHistoryReporter in `directory`")
+	suite := flags.String("suite", "", "only consider runs of the suite with this description")
+	limit := flags.Int("n", 0, "only consider the last `n` runs of each suite, 0 for all")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: biloba history [flags] <report>\n\n")
+		fmt.Fprintf(flags.Output(), "The reports are:\n\n")
+		fmt.Fprintf(flags.Output(), "\tflaky         specs that passed on retry or changed outcome between runs\n")
+		fmt.Fprintf(flags.Output(), "\tdurations     spec durations across runs, most slowed down first\n")
+		fmt.Fprintf(flags.Output(), "\tnew-failures  specs that failed in the last run but not the one before\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a single report")
+	}
+
+	runs, err := biloba.ReadHistory(*dir)
+	if err != nil {
+		return err
+	}
+	runs = lastRuns(filterSuite(runs, *suite), *limit)
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	switch flags.Arg(0) {
+	case "flaky":
+		printFlakiness(out, biloba.Flakiness(runs))
+	case "durations":
+		printDurationTrends(out, biloba.DurationTrends(runs))
+	case "new-failures":
+		printNewlyFailing(out, biloba.NewlyFailing(runs))
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown report %q", flags.Arg(0))
+	}
+	return out.Flush()
+}
+
+func filterSuite(runs []biloba.HistoryRun, suite string) []biloba.HistoryRun {
+	if suite == "" {
+		return runs
+	}
+	var filtered []biloba.HistoryRun
+	for _, run := range runs {
+		if run.Suite == suite {
+			filtered = append(filtered, run)
+		}
+	}
+	return filtered
+}
+
+func lastRuns(runs []biloba.HistoryRun, limit int) []biloba.HistoryRun {
+	if limit <= 0 {
+		return runs
+	}
+	seen := map[string]int{}
+	var kept []biloba.HistoryRun
+	for i := len(runs) - 1; i >= 0; i-- {
+		if seen[runs[i].Suite] < limit {
+			seen[runs[i].Suite]++
+			kept = append([]biloba.HistoryRun{runs[i]}, kept...)
+		}
+	}
+	return kept
+}
+
+func printFlakiness(out *tabwriter.Writer, flakiness []biloba.SpecFlakiness) {
+	if len(flakiness) == 0 {
+		fmt.Fprintln(out, "No flaky specs.")
+		return
+	}
+	fmt.Fprintln(out, "RATE\tRUNS\tFAILED\tFLAKY\tFLIPS\tSPEC\tLOCATION")
+	for _, f := range flakiness {
+		fmt.Fprintf(out, "%.0f%%\t%d\t%d\t%d\t%d\t%s\t%s\n", 100*f.Rate(), f.Runs, f.Failures, f.Flaky, f.Flips, f.Name, f.Location)
+	}
+}
+
+func printDurationTrends(out *tabwriter.Writer, trends []biloba.SpecDurationTrend) {
+	if len(trends) == 0 {
+		fmt.Fprintln(out, "No specs ran.")
+		return
+	}
+	fmt.Fprintln(out, "CHANGE\tMEAN\tDURATIONS\tSPEC")
+	for _, trend := range trends {
+		durations := make([]string, len(trend.Durations))
+		for i, duration := range trend.Durations {
+			durations[i] = formatSeconds(duration)
+		}
+		fmt.Fprintf(out, "%+.0f%%\t%s\t%s\t%s\n", 100*trend.Change(), formatSeconds(trend.Mean()), strings.Join(durations, " "), trend.Name)
+	}
+}
+
+func printNewlyFailing(out *tabwriter.Writer, specs []biloba.HistorySpec) {
+	if len(specs) == 0 {
+		fmt.Fprintln(out, "No newly failing specs.")
+		return
+	}
+	fmt.Fprintln(out, "STATE\tSPEC\tLOCATION")
+	for _, spec := range specs {
+		fmt.Fprintf(out, "%s\t%s\t%s\n", spec.State, spec.Name, spec.Location)
+	}
+}
+
+func formatSeconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3fs", duration.Seconds())
+}
@@ -0,0 +1,58 @@
+// Command biloba works with the artifacts written by biloba's reporters.
+//
+// Usage:
+//
+//	biloba <command> [arguments]
+//
+// The commands are:
+//
+//	diff      compare the specs of two reports
+//	history   analyze the run history for flaky, slowing and newly failing specs
+//	replay    feed a recorded event log through ginkgo reporters
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"diff":    {summary: "compare the specs of two reports", run: diff},
+	"history": {summary: "analyze the run history for flaky, slowing and newly failing specs", run: history},
+	"replay":  {summary: "feed a recorded event log through ginkgo reporters", run: replay},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "biloba: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba %s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: biloba <command> [arguments]\n\nThe commands are:\n\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-10s%s\n", name, commands[name].summary)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/matt-royal/biloba"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/reporters"
+	"github.com/onsi/ginkgo/reporters/stenographer"
+)
+
+// replay runs "biloba replay [flags] <event log>".
+func replay(args []string) error {
+	flags := flag.NewFlagSet("biloba replay", flag.ContinueOnError)
+	gotest := flags.Bool("gotest", true, "print go test compatible output, as biloba.NewGoTestCompatibleReporter does")
+	defaultReporter := flags.Bool("default", false, "print the output of ginkgo's default reporter")
+	teamcity := flags.Bool("teamcity", false, "print the output of ginkgo's TeamCity reporter")
+	noColor := flags.Bool("noColor", false, "print the default reporter's output without colors")
+	jsonFile := flags.String("json", "", "write a biloba JSON report to `file`")
+	junitFile := flags.String("junit", "", "write a biloba JUnit report to `file`")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: biloba replay [flags] <event log>\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a single event log")
+	}
+
+	var replayed []ginkgo.Reporter
+	if *gotest {
+		replayed = append(replayed, biloba.NewGoTestCompatibleReporter())
+	}
+	if *teamcity {
+		replayed = append(replayed, reporters.NewTeamCityReporter(os.Stdout))
+	}
+	if *jsonFile != "" {
+		replayed = append(replayed, biloba.NewJSONReporter(*jsonFile))
+	}
+	if *junitFile != "" {
+		replayed = append(replayed, biloba.NewJUnitReporter(*junitFile))
+	}
+	if *defaultReporter {
+		// last, as with ginkgo.RunSpecsWithDefaultAndCustomReporters
+		reporterConfig := config.DefaultReporterConfig
+		reporterConfig.NoColor = *noColor
+		replayed = append(replayed, reporters.NewDefaultReporter(reporterConfig, stenographer.New(!*noColor, false, os.Stdout)))
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return biloba.Replay(file, replayed...)
+}
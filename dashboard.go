@@ -0,0 +1,194 @@
+package biloba
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/matt-royal/biloba/events"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// dashboardClientBuffer is how many events a slow dashboard client may lag
+// behind before it is disconnected.
+const dashboardClientBuffer = 256
+
+// dashboardShutdownTimeout is how long the dashboard server waits for the
+// event streams to end once the suite ended.
+const dashboardShutdownTimeout = 5 * time.Second
+
+type dashboardReporter struct {
+	*eventEmitter
+	port int
+
+	mu      sync.Mutex
+	history [][]byte
+	clients map[chan []byte]bool
+	ended   bool
+	server  *http.Server
+}
+
+// NewDashboardReporter serves a page showing the suite as it runs on
+// http://127.0.0.1:<port>/, streaming the events of the suite to it as
+// server-sent events. Port 0 picks a free port; the address is printed to
+// stderr when the suite begins. The server only listens on the loopback
+// interface.
+func NewDashboardReporter(port int) *dashboardReporter {
+	r := &dashboardReporter{port: port, clients: map[chan []byte]bool{}}
+	r.eventEmitter = &eventEmitter{emit: r.publish}
+	return r
+}
+
+func (r *dashboardReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.mu.Lock()
+	r.history = nil
+	r.ended = false
+	r.mu.Unlock()
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(r.port)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to start dashboard: %s\n", err)
+	} else {
+		r.server = &http.Server{Handler: r.Handler()}
+		go r.server.Serve(listener)
+		fmt.Fprintf(os.Stderr, "biloba: dashboard at http://%s/\n", listener.Addr())
+	}
+
+	r.eventEmitter.SpecSuiteWillBegin(config, summary)
+}
+
+// SpecSuiteDidEnd publishes the end of the suite, ends the event streams and
+// shuts the server down.
+func (r *dashboardReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.eventEmitter.SpecSuiteDidEnd(summary)
+
+	r.mu.Lock()
+	r.ended = true
+	for client := range r.clients {
+		delete(r.clients, client)
+		close(client)
+	}
+	r.mu.Unlock()
+
+	if r.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dashboardShutdownTimeout)
+	defer cancel()
+	if err := r.server.Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to stop dashboard: %s\n", err)
+	}
+	r.server = nil
+}
+
+// Handler serves the dashboard page on / and the stream of events on
+// /events. Clients that connect late first receive the events they missed.
+func (r *dashboardReporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", r.servePage)
+	mux.HandleFunc("/events", r.serveEvents)
+	return mux
+}
+
+func (r *dashboardReporter) servePage(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, dashboardPage)
+}
+
+func (r *dashboardReporter) serveEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	client, history := r.subscribe()
+	defer r.unsubscribe(client)
+	for _, event := range history {
+		writeServerSentEvent(w, event)
+	}
+	flusher.Flush()
+	if client == nil {
+		// the suite already ended
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-client:
+			if !ok {
+				return
+			}
+			writeServerSentEvent(w, event)
+			flusher.Flush()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, data []byte) {
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+func (r *dashboardReporter) subscribe() (chan []byte, [][]byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := append([][]byte(nil), r.history...)
+	if r.ended {
+		return nil, history
+	}
+	client := make(chan []byte, dashboardClientBuffer)
+	r.clients[client] = true
+	return client, history
+}
+
+func (r *dashboardReporter) unsubscribe(client chan []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.clients[client] {
+		delete(r.clients, client)
+		close(client)
+	}
+}
+
+func (r *dashboardReporter) publish(event events.Event) {
+	event.Version = events.Version
+	data, err := json.Marshal(event)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to publish event to dashboard: %s\n", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.history = append(r.history, data)
+	for client := range r.clients {
+		select {
+		case client <- data:
+		default:
+			// too far behind; the page reconnects and catches up from the history
+			delete(r.clients, client)
+			close(client)
+		}
+	}
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(dashboardReporter)
@@ -0,0 +1,155 @@
+package biloba
+
+// dashboardPage is the page served by the dashboard reporter. It rebuilds the
+// state of the run from the stream of events, which starts over from the
+// beginning of the suite whenever it reconnects. Spec states are the values
+// of ginkgo's types.SpecState.
+const dashboardPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>biloba dashboard</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.1em; margin-top: 1.5em; }
+#status span { margin-right: 1em; }
+#current { font-family: monospace; }
+ul { list-style: none; padding-left: 1.2em; margin: 0; }
+.passed { color: #22863a; }
+.failed { color: #cb2431; }
+.skipped { color: #6a737d; }
+.state { display: inline-block; width: 4.5em; font-weight: bold; font-size: 0.8em; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1 id="suite">Waiting for the suite to begin</h1>
+<div id="status"></div>
+<h2>Running</h2>
+<div id="current">-</div>
+<h2>Failures</h2>
+<div id="failures"></div>
+<h2>Specs</h2>
+<ul id="tree"></ul>
+<script>
+(function () {
+  var state;
+
+  function reset(suite) {
+    state = { total: suite.NumberOfSpecsThatWillBeRun, done: 0, failed: 0, started: Date.now(), ended: false, nodes: {}, failures: {} };
+    document.getElementById("suite").textContent = suite.SuiteDescription;
+    document.getElementById("failures").textContent = "";
+    document.getElementById("tree").textContent = "";
+    document.getElementById("current").textContent = "-";
+    status();
+  }
+
+  function stateOf(spec) {
+    switch (spec.State) {
+      case 3: return "passed";
+      case 1: case 2: return "skipped";
+      default: return "failed";
+    }
+  }
+
+  function containerList(texts) {
+    var list = document.getElementById("tree");
+    var path = "";
+    texts.slice(1, -1).forEach(function (text) {
+      path += "\u0000" + text;
+      var node = state.nodes[path];
+      if (!node) {
+        var item = document.createElement("li");
+        item.textContent = text;
+        node = document.createElement("ul");
+        item.appendChild(node);
+        list.appendChild(item);
+        state.nodes[path] = node;
+      }
+      list = node;
+    });
+    return list;
+  }
+
+  function completed(event) {
+    var spec = event.spec;
+    var result = stateOf(spec);
+    var texts = spec.ComponentTexts;
+    var id = "spec-" + event.spec_id;
+    var item = document.getElementById(id);
+    if (!item) {
+      item = document.createElement("li");
+      item.id = id;
+      containerList(texts).appendChild(item);
+      if (result !== "skipped") {
+        state.done++;
+      }
+    }
+    item.className = result;
+    item.innerHTML = "";
+    var label = document.createElement("span");
+    label.className = "state";
+    label.textContent = result;
+    item.appendChild(label);
+    item.appendChild(document.createTextNode(texts[texts.length - 1] + " (" + (spec.RunTime / 1e9).toFixed(3) + "s)"));
+
+    var failure = state.failures[id];
+    if (failure) {
+      // an earlier attempt of the spec failed
+      failure.parentNode.removeChild(failure);
+      delete state.failures[id];
+      state.failed--;
+    }
+    if (result === "failed") {
+      state.failed++;
+      failure = document.createElement("div");
+      var name = document.createElement("strong");
+      name.className = "failed";
+      name.textContent = texts.slice(1).join(" ");
+      var message = document.createElement("pre");
+      message.textContent = spec.Failure.Message + "\n" + spec.Failure.Location.FileName + ":" + spec.Failure.Location.LineNumber;
+      failure.appendChild(name);
+      failure.appendChild(message);
+      document.getElementById("failures").appendChild(failure);
+      state.failures[id] = failure;
+    }
+    document.getElementById("current").textContent = "-";
+    status();
+  }
+
+  function status() {
+    var elapsed = Math.round((Date.now() - state.started) / 1000);
+    document.getElementById("status").innerHTML =
+      "<span>" + state.done + "/" + state.total + " specs</span>" +
+      "<span class=\"failed\">" + state.failed + " failed</span>" +
+      "<span>" + (state.ended ? "finished" : elapsed + "s elapsed") + "</span>";
+  }
+
+  var source = new EventSource("events");
+  source.onmessage = function (message) {
+    var event = JSON.parse(message.data);
+    switch (event.type) {
+      case "suite_will_begin":
+        reset(event.suite);
+        break;
+      case "spec_will_run":
+        document.getElementById("current").textContent = event.spec.ComponentTexts.slice(1).join(" ");
+        break;
+      case "spec_did_complete":
+        completed(event);
+        break;
+      case "suite_did_end":
+        // the server shuts down with the suite, so don't reconnect
+        source.close();
+        state.ended = true;
+        status();
+        break;
+    }
+  };
+  setInterval(function () { if (state && !state.ended) { status(); } }, 1000);
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,112 @@
+package biloba_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/matt-royal/biloba"
+	"github.com/matt-royal/biloba/events"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DashboardReporter", func() {
+	var (
+		reporter interface {
+			Reporter
+			Handler() http.Handler
+		}
+		server *httptest.Server
+		spec   *types.SpecSummary
+	)
+
+	BeforeEach(func() {
+		reporter = biloba.NewDashboardReporter(0)
+		server = httptest.NewServer(reporter.Handler())
+		spec = &types.SpecSummary{
+			ComponentTexts:         []string{"[Top Level]", "level 1", "test 1 fails"},
+			ComponentCodeLocations: []types.CodeLocation{{}, {FileName: "dashboard_test.go", LineNumber: 1}, {FileName: "dashboard_test.go", LineNumber: 2}},
+			State:                  types.SpecStateFailed,
+			Failure:                types.SpecFailure{Message: "Expected failure"},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("serves the dashboard page", func() {
+		response, err := http.Get(server.URL + "/")
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+
+		page, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+		Expect(string(page)).To(ContainSubstring("<title>biloba dashboard</title>"))
+		Expect(string(page)).To(ContainSubstring(`new EventSource("events")`))
+	})
+
+	It("streams past and live events to the page", func() {
+		reporter.SpecSuiteWillBegin(config.GinkgoConfigType{}, &types.SuiteSummary{SuiteDescription: "Dashboard Suite", NumberOfSpecsThatWillBeRun: 1})
+		reporter.SpecWillRun(spec)
+
+		response, err := http.Get(server.URL + "/events")
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		Expect(response.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		received := make(chan events.Event)
+		go func() {
+			defer GinkgoRecover()
+			scanner := bufio.NewScanner(response.Body)
+			for scanner.Scan() {
+				if !strings.HasPrefix(scanner.Text(), "data: ") {
+					continue
+				}
+				var event events.Event
+				Expect(json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &event)).To(Succeed())
+				received <- event
+			}
+			close(received)
+		}()
+
+		var event events.Event
+		Eventually(received).Should(Receive(&event))
+		Expect(event.Type).To(Equal(events.SuiteWillBegin))
+		Expect(event.Suite.SuiteDescription).To(Equal("Dashboard Suite"))
+		Eventually(received).Should(Receive(&event))
+		Expect(event.Type).To(Equal(events.SpecWillRun))
+
+		reporter.SpecDidComplete(spec)
+		Eventually(received).Should(Receive(&event))
+		Expect(event.Type).To(Equal(events.SpecDidComplete))
+		Expect(event.Spec.Failure.Message).To(Equal("Expected failure"))
+		Expect(event.SpecID).To(Equal(biloba.SpecID(spec)))
+
+		reporter.SpecSuiteDidEnd(&types.SuiteSummary{SuiteDescription: "Dashboard Suite", SuiteSucceeded: false})
+		Eventually(received).Should(Receive(&event))
+		Expect(event.Type).To(Equal(events.SuiteDidEnd))
+		Eventually(received).Should(BeClosed())
+	})
+
+	It("ends the stream of clients that connect after the suite ended", func() {
+		reporter.SpecSuiteWillBegin(config.GinkgoConfigType{}, &types.SuiteSummary{SuiteDescription: "Dashboard Suite", NumberOfSpecsThatWillBeRun: 0})
+		reporter.SpecSuiteDidEnd(&types.SuiteSummary{SuiteDescription: "Dashboard Suite", SuiteSucceeded: true})
+
+		response, err := http.Get(server.URL + "/events")
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+
+		stream, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Count(string(stream), "data: ")).To(Equal(2))
+		Expect(string(stream)).To(ContainSubstring(`"type":"suite_did_end"`))
+	})
+})
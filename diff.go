@@ -0,0 +1,220 @@
+package biloba
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+// ReportDiff is how the specs of a run changed compared to an earlier run.
+type ReportDiff struct {
+	// NewlyFailing failed in the new run but not in the old one, and
+	// NewlyPassing passed in the new run after failing in the old one
+	NewlyFailing []SpecReport
+	NewlyPassing []SpecReport
+	Added        []SpecReport
+	Removed      []SpecReport
+	Renamed      []SpecRename
+	Slower       []DurationRegression
+}
+
+// SpecRename is a spec whose text changed between runs while its code location
+// did not.
+type SpecRename struct {
+	Old SpecReport
+	New SpecReport
+}
+
+// Empty reports whether the runs had no differences.
+func (d ReportDiff) Empty() bool {
+	return len(d.NewlyFailing)+len(d.NewlyPassing)+len(d.Added)+len(d.Removed)+len(d.Renamed)+len(d.Slower) == 0
+}
+
+// DiffReports compares the specs of two runs. Specs are matched by ID, or by
+// their full text when either of them has no ID, as in JUnit files that
+// biloba didn't write. Specs that are only in one of the runs are matched by
+// code location, as renamed. A spec is slower when it passed in both runs and
+// took more than ratio times and more than threshold longer in the new one; a
+// ratio of 0 only applies the threshold.
+func DiffReports(old, new Report, ratio float64, threshold time.Duration) ReportDiff {
+	oldByID := map[string]int{}
+	oldByName := map[string]int{}
+	for i, spec := range old.Specs {
+		if spec.ID != "" {
+			oldByID[spec.ID] = i
+		}
+		oldByName[fullSpecName(spec)] = i
+	}
+
+	// the index of the old spec each new spec matches, -1 for none
+	matches := make([]int, len(new.Specs))
+	matched := map[int]bool{}
+	for i, spec := range new.Specs {
+		matches[i] = -1
+		if j, ok := oldByID[spec.ID]; ok && spec.ID != "" {
+			matches[i] = j
+			matched[j] = true
+		}
+	}
+	for i, spec := range new.Specs {
+		if matches[i] != -1 {
+			continue
+		}
+		if j, ok := oldByName[fullSpecName(spec)]; ok && !matched[j] && (spec.ID == "" || old.Specs[j].ID == "") {
+			matches[i] = j
+			matched[j] = true
+		}
+	}
+
+	// specs only in the old run, by location, for finding renames
+	removedByLocation := map[string][]int{}
+	for j, spec := range old.Specs {
+		if !matched[j] && spec.Location != "" {
+			removedByLocation[spec.Location] = append(removedByLocation[spec.Location], j)
+		}
+	}
+
+	var diff ReportDiff
+	for i, spec := range new.Specs {
+		j := matches[i]
+		if j == -1 {
+			candidates := removedByLocation[spec.Location]
+			if spec.Location == "" || len(candidates) == 0 {
+				diff.Added = append(diff.Added, spec)
+				continue
+			}
+			j = candidates[0]
+			removedByLocation[spec.Location] = candidates[1:]
+			matched[j] = true
+			diff.Renamed = append(diff.Renamed, SpecRename{Old: old.Specs[j], New: spec})
+		}
+		oldSpec := old.Specs[j]
+
+		passed := stateName(types.SpecStatePassed)
+		switch {
+		case failedState(spec.State) && !failedState(oldSpec.State):
+			diff.NewlyFailing = append(diff.NewlyFailing, spec)
+		case spec.State == passed && failedState(oldSpec.State):
+			diff.NewlyPassing = append(diff.NewlyPassing, spec)
+		case spec.State == passed && oldSpec.State == passed && slowedDown(oldSpec.RunTime, spec.RunTime, ratio, threshold):
+			diff.Slower = append(diff.Slower, DurationRegression{
+				ID:       spec.ID,
+				Name:     spec.Name,
+				Location: spec.Location,
+				Baseline: oldSpec.RunTime,
+				RunTime:  spec.RunTime,
+			})
+		}
+	}
+
+	for j, spec := range old.Specs {
+		if !matched[j] {
+			diff.Removed = append(diff.Removed, spec)
+		}
+	}
+	sort.SliceStable(diff.Slower, func(i, j int) bool {
+		return diff.Slower[i].RunTime-diff.Slower[i].Baseline > diff.Slower[j].RunTime-diff.Slower[j].Baseline
+	})
+	return diff
+}
+
+// fullSpecName is the text of a spec as ginkgo's JUnit reporter names test
+// cases: its component texts, below the top level, joined with spaces. The
+// Name of a spec in biloba's reports escapes parentheses for GoLand.
+func fullSpecName(spec SpecReport) string {
+	if len(spec.ComponentTexts) > 0 {
+		return strings.Join(spec.ComponentTexts, " ")
+	}
+	return spec.Name
+}
+
+// junitResult is a testcase as written by any JUnit reporter, not only
+// biloba's.
+type junitResult struct {
+	junitTestCase
+	Error *junitMessage `xml:"error"`
+}
+
+// ReadJUnitReport loads a JUnit XML file, such as those written by biloba's or
+// ginkgo's JUnit reporters, as a Report. Only test cases with the id and
+// location properties that biloba writes have an ID and a location.
+func ReadJUnitReport(filename string) (Report, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var suites struct {
+		XMLName xml.Name
+		Name    string        `xml:"name,attr"`
+		Time    float64       `xml:"time,attr"`
+		Cases   []junitResult `xml:"testcase"`
+		Suites  []struct {
+			Name  string        `xml:"name,attr"`
+			Time  float64       `xml:"time,attr"`
+			Cases []junitResult `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Suite: suites.Name, Succeeded: true, RunTime: seconds(suites.Time), Specs: []SpecReport{}}
+	cases := suites.Cases
+	for _, suite := range suites.Suites {
+		cases = append(cases, suite.Cases...)
+		if suites.Time == 0 {
+			report.RunTime += seconds(suite.Time)
+		}
+		if report.Suite == "" {
+			report.Suite = suite.Name
+		}
+	}
+	for _, testCase := range cases {
+		spec := SpecReport{
+			Name:    testCase.Name,
+			State:   stateName(types.SpecStatePassed),
+			RunTime: seconds(testCase.Time),
+			Output:  testCase.SystemOut,
+		}
+		if testCase.Properties != nil {
+			for _, property := range testCase.Properties.Properties {
+				switch property.Name {
+				case "id":
+					spec.ID = property.Value
+				case "location":
+					spec.Location = property.Value
+				}
+			}
+		}
+		switch {
+		case testCase.Failure != nil:
+			// biloba's JUnit reporter records the state as the failure type
+			switch testCase.Failure.Type {
+			case stateName(types.SpecStatePanicked), stateName(types.SpecStateTimedOut):
+				spec.State = testCase.Failure.Type
+			default:
+				spec.State = stateName(types.SpecStateFailed)
+			}
+			spec.Failure = strings.TrimSpace(testCase.Failure.Details)
+		case testCase.Error != nil:
+			spec.State = stateName(types.SpecStatePanicked)
+			spec.Failure = strings.TrimSpace(testCase.Error.Details)
+		case testCase.Skipped != nil:
+			spec.State = stateName(types.SpecStateSkipped)
+		}
+		if failedState(spec.State) {
+			report.Succeeded = false
+		}
+		report.Specs = append(report.Specs, spec)
+	}
+	return report, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,79 @@
+package biloba
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/matt-royal/biloba/events"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+type eventLogReporter struct {
+	*eventEmitter
+	mu       sync.Mutex
+	filename string
+	file     *os.File
+	writer   *events.Writer
+}
+
+// NewEventLogReporter writes every event of the suite to filename as it
+// happens, in the newline-delimited JSON format of the biloba/events package,
+// so the log is complete up to the last event even if the process dies.
+func NewEventLogReporter(filename string) *eventLogReporter {
+	r := &eventLogReporter{filename: filename}
+	r.eventEmitter = &eventEmitter{emit: r.write}
+	return r
+}
+
+func (r *eventLogReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		r.fail(err)
+	} else if r.file, err = os.Create(r.filename); err != nil {
+		r.fail(err)
+	} else {
+		r.writer = events.NewWriter(r.file)
+	}
+	r.mu.Unlock()
+
+	r.eventEmitter.SpecSuiteWillBegin(config, summary)
+}
+
+func (r *eventLogReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.eventEmitter.SpecSuiteDidEnd(summary)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			r.fail(err)
+		}
+		r.file = nil
+		r.writer = nil
+	}
+}
+
+func (r *eventLogReporter) write(event events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.writer == nil {
+		return
+	}
+
+	if err := r.writer.Write(event); err != nil {
+		r.fail(err)
+	}
+}
+
+// fail stops the event log after reporting why.
+func (r *eventLogReporter) fail(err error) {
+	fmt.Fprintf(os.Stderr, "biloba: failed to write event log: %s\n", err)
+	r.writer = nil
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(eventLogReporter)
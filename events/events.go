@@ -0,0 +1,122 @@
+// Package events defines the event log written by biloba's event log
+// reporter: one JSON object per line for each event ginkgo reports, carrying
+// ginkgo's own summaries so that no data is lost.
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// Version is the version of the event log format written by this package.
+// Readers reject events of other versions.
+const Version = 1
+
+// Type is the ginkgo.Reporter method an event was reported with.
+type Type string
+
+const (
+	SuiteWillBegin    Type = "suite_will_begin"
+	BeforeSuiteDidRun Type = "before_suite_did_run"
+	SpecWillRun       Type = "spec_will_run"
+	SpecDidComplete   Type = "spec_did_complete"
+	AfterSuiteDidRun  Type = "after_suite_did_run"
+	SuiteDidEnd       Type = "suite_did_end"
+)
+
+// Event is a single line of the event log. Which of its fields are set
+// depends on its type:
+//
+//	suite_will_begin                       Config, Suite, Package, TestFunc
+//	before_suite_did_run, after_suite_did_run  Setup
+//	spec_will_run, spec_did_complete       Spec, SpecID
+//	suite_did_end                          Suite
+type Event struct {
+	Version int       `json:"version"`
+	Type    Type      `json:"type"`
+	Time    time.Time `json:"time"`
+
+	Config *config.GinkgoConfigType `json:"config,omitempty"`
+	Suite  *types.SuiteSummary      `json:"suite,omitempty"`
+	Setup  *types.SetupSummary      `json:"setup,omitempty"`
+	Spec   *types.SpecSummary       `json:"spec,omitempty"`
+	SpecID string                   `json:"spec_id,omitempty"`
+
+	// Package is the import path of the package under test and TestFunc the
+	// go test function that ran the suite, empty in logs of older versions
+	Package  string `json:"package,omitempty"`
+	TestFunc string `json:"test_func,omitempty"`
+}
+
+// Writer writes events to an event log.
+type Writer struct {
+	encoder *json.Encoder
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{encoder: json.NewEncoder(w)}
+}
+
+// Write writes an event as a line of JSON, setting its version.
+func (w *Writer) Write(event Event) error {
+	event.Version = Version
+	return w.encoder.Encode(event)
+}
+
+// Reader reads the events of an event log in order.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	// spec summaries carry captured output, so lines can be long
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	return &Reader{scanner: scanner}
+}
+
+// Next returns the next event, or io.EOF once all events were read.
+func (r *Reader) Next() (Event, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(r.scanner.Bytes(), &event); err != nil {
+			return Event{}, fmt.Errorf("event log line %d: %s", r.line, err)
+		}
+		if event.Version != Version {
+			return Event{}, fmt.Errorf("event log line %d: unsupported version %d", r.line, event.Version)
+		}
+		return event, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
+
+// ReadAll reads all the events of an event log.
+func ReadAll(r io.Reader) ([]Event, error) {
+	reader := NewReader(r)
+	var events []Event
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
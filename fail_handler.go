@@ -0,0 +1,35 @@
+package biloba
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+// Fail is a drop-in replacement for ginkgo.Fail that applies biloba's spec
+// policies before failing the spec. Register it with gomega instead of
+// ginkgo.Fail:
+//
+//	RegisterFailHandler(biloba.Fail)
+//
+// Failures of quarantined specs skip the spec instead of failing the suite.
+// Failures of specs that are expected to fail (see ExpectFailure and XFailTag)
+// also end the spec without failing the suite, and biloba reports them as
+// passing.
+func Fail(message string, callerSkip ...int) {
+	skip := 0
+	if len(callerSkip) > 0 {
+		skip = callerSkip[0]
+	}
+
+	description := ginkgo.CurrentGinkgoTestDescription()
+	location := types.CodeLocation{FileName: description.FileName, LineNumber: description.LineNumber}
+	if entry := loadedQuarantine().find(description.FullTestText, location.String()); entry != nil {
+		skipFailure(description)
+		ginkgo.Skip(quarantinedFailurePrefix+message, skip+1)
+	}
+	if failureExpected(description.FullTestText) {
+		skipFailure(description)
+		ginkgo.Skip(expectedFailurePrefix+message, skip+1)
+	}
+	ginkgo.Fail(message, skip+1)
+}
@@ -0,0 +1,104 @@
+package biloba
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// DefaultFailedSpecsFile is where failed specs are recorded, relative to the
+// package under test.
+const DefaultFailedSpecsFile = ".biloba/failed.json"
+
+// FailedSpecs lists the specs that failed in the last run of a suite.
+type FailedSpecs struct {
+	Suite string       `json:"suite"`
+	Specs []FailedSpec `json:"specs"`
+}
+
+type FailedSpec struct {
+	ComponentTexts []string           `json:"component_texts"`
+	Location       types.CodeLocation `json:"location"`
+}
+
+type failedSpecsReporter struct {
+	config   config.GinkgoConfigType
+	filename string
+	failed   FailedSpecs
+	attempts attemptCounter
+}
+
+// NewFailedSpecsReporter records the specs that fail to filename when the
+// suite ends. When BILOBA_RERUN_FAILED is set, it also focuses ginkgo on the
+// specs recorded by the previous run, so it must be created before the
+// suite starts, i.e. in the arguments to ginkgo.RunSpecsWithCustomReporters.
+func NewFailedSpecsReporter(filename string) *failedSpecsReporter {
+	if os.Getenv("BILOBA_RERUN_FAILED") != "" {
+		focusOnFailedSpecs(filename)
+	}
+	return &failedSpecsReporter{
+		filename: filename,
+		attempts: attemptCounter{},
+	}
+}
+
+func focusOnFailedSpecs(filename string) {
+	var failed FailedSpecs
+	if err := readJSON(filename, &failed); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: not rerunning failed specs: %s\n", err)
+		return
+	}
+	if len(failed.Specs) == 0 {
+		fmt.Fprintf(os.Stderr, "biloba: no failed specs recorded in %s, running all specs\n", filename)
+		return
+	}
+	if config.GinkgoConfig.FocusString != "" {
+		fmt.Fprintf(os.Stderr, "biloba: not rerunning failed specs: -ginkgo.focus is already set\n")
+		return
+	}
+
+	patterns := make([]string, len(failed.Specs))
+	for i, spec := range failed.Specs {
+		patterns[i] = focusPattern(failed.Suite, config.GinkgoConfig, spec.ComponentTexts, spec.Location)
+	}
+	config.GinkgoConfig.FocusString = "^(" + strings.Join(patterns, "|") + ")$"
+}
+
+func (r *failedSpecsReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.config = config
+	r.failed = FailedSpecs{Suite: summary.SuiteDescription, Specs: []FailedSpec{}}
+}
+
+func (r *failedSpecsReporter) SpecWillRun(spec *types.SpecSummary) {
+	r.attempts.start(spec)
+}
+
+func (r *failedSpecsReporter) SpecDidComplete(spec *types.SpecSummary) {
+	// only the last of several flake attempts decides whether the spec failed
+	if !spec.HasFailureState() || r.attempts.current(spec) < r.config.FlakeAttempts {
+		return
+	}
+	r.failed.Specs = append(r.failed.Specs, FailedSpec{
+		ComponentTexts: spec.ComponentTexts,
+		Location:       specLocation(spec),
+	})
+}
+
+func (r *failedSpecsReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	if err := writeJSON(r.filename, r.failed); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to record failed specs: %s\n", err)
+	}
+}
+
+// No-Op methods for compatibility with ginkgo.Reporter
+
+func (r *failedSpecsReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+func (r *failedSpecsReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+// force compatibility
+var _ ginkgo.Reporter = new(failedSpecsReporter)
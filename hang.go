@@ -0,0 +1,70 @@
+package biloba
+
+import (
+	"sync"
+	"time"
+)
+
+var hangs = &hangConfig{}
+
+type hangConfig struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	interval  time.Duration
+}
+
+// WatchForHangs reports specs that seem to hang: once a spec has run for
+// longer than threshold, the reporter from NewGoTestCompatibleReporter prints
+// its name, how long it has been running and a dump of all goroutines in the
+// spec's go test output, and to stderr as well on parallel nodes. The report
+// repeats every interval until the spec ends. Call it before RunSpecs.
+func WatchForHangs(threshold time.Duration, interval time.Duration) {
+	hangs.mu.Lock()
+	defer hangs.mu.Unlock()
+
+	hangs.threshold = threshold
+	hangs.interval = interval
+}
+
+// watchdog calls report with the elapsed time once its threshold passes and
+// every interval after that, until it is stopped.
+type watchdog struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+// startWatchdog returns nil unless WatchForHangs was called.
+func startWatchdog(report func(elapsed time.Duration)) *watchdog {
+	hangs.mu.Lock()
+	threshold, interval := hangs.threshold, hangs.interval
+	hangs.mu.Unlock()
+	if threshold <= 0 {
+		return nil
+	}
+
+	w := &watchdog{stop: make(chan struct{})}
+	go func(started time.Time) {
+		timer := time.NewTimer(threshold)
+		defer timer.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-timer.C:
+				report(time.Since(started))
+				if interval <= 0 {
+					return
+				}
+				timer.Reset(interval)
+			}
+		}
+	}(time.Now())
+	return w
+}
+
+func (w *watchdog) Stop() {
+	if w == nil {
+		return
+	}
+	w.once.Do(func() { close(w.stop) })
+}
@@ -0,0 +1,305 @@
+package biloba
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+// DefaultHistoryDir is where the history reporter keeps past runs, relative
+// to the package under test.
+const DefaultHistoryDir = ".biloba/history"
+
+// historyFile is the file in a history directory that runs are appended to,
+// one JSON object per line.
+const historyFile = "runs.jsonl"
+
+// HistoryRun is a run of a suite as recorded in the history.
+type HistoryRun struct {
+	Suite     string        `json:"suite"`
+	Time      time.Time     `json:"time"`
+	Succeeded bool          `json:"succeeded"`
+	RunTime   time.Duration `json:"run_time"`
+	Specs     []HistorySpec `json:"specs"`
+}
+
+// HistorySpec is the outcome of a spec in a run.
+type HistorySpec struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Location string        `json:"location"`
+	State    string        `json:"state"`
+	RunTime  time.Duration `json:"run_time"`
+	Flaky    bool          `json:"flaky,omitempty"`
+}
+
+func (s HistorySpec) failed() bool {
+	return failedState(s.State)
+}
+
+func (s HistorySpec) ran() bool {
+	return s.State != stateName(types.SpecStateSkipped) && s.State != stateName(types.SpecStatePending)
+}
+
+type historyReporter struct {
+	*reportCollector
+	dir     string
+	started time.Time
+}
+
+// NewHistoryReporter appends the outcome of every spec of the suite to the
+// history kept in dir once the suite ends, for the biloba history command to
+// analyze across runs.
+func NewHistoryReporter(dir string) *historyReporter {
+	r := &historyReporter{dir: dir, started: time.Now()}
+	r.reportCollector = newReportCollector(r.appendRun)
+	return r
+}
+
+func (r *historyReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.finish(summary)
+}
+
+func (r *historyReporter) appendRun(report Report) {
+	run := HistoryRun{
+		Suite:     report.Suite,
+		Time:      r.started,
+		Succeeded: report.Succeeded,
+		RunTime:   report.RunTime,
+		Specs:     make([]HistorySpec, len(report.Specs)),
+	}
+	for i, spec := range report.Specs {
+		run.Specs[i] = HistorySpec{
+			ID:       spec.ID,
+			Name:     spec.Name,
+			Location: spec.Location,
+			State:    spec.State,
+			RunTime:  spec.RunTime,
+			Flaky:    spec.Flaky,
+		}
+	}
+
+	if err := appendHistory(r.dir, run); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to record run history: %s\n", err)
+	}
+}
+
+func appendHistory(dir string, run HistoryRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, historyFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadHistory reads the runs recorded in dir, oldest first.
+func ReadHistory(dir string) ([]HistoryRun, error) {
+	file, err := os.Open(filepath.Join(dir, historyFile))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readHistory(file)
+}
+
+func readHistory(r io.Reader) ([]HistoryRun, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	var runs []HistoryRun
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var run HistoryRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("history line %d: %s", line, err)
+		}
+		runs = append(runs, run)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Time.Before(runs[j].Time)
+	})
+	return runs, scanner.Err()
+}
+
+// SpecFlakiness is how often a spec's outcome was unreliable.
+type SpecFlakiness struct {
+	ID       string
+	Name     string
+	Location string
+	Runs     int
+	Failures int
+	// Flaky counts the runs in which the spec only passed on retry, and
+	// Flips those in which it passed after failing in the previous run, or
+	// failed after passing
+	Flaky int
+	Flips int
+}
+
+// Rate is the share of runs in which the spec was flaky or flipped.
+func (f SpecFlakiness) Rate() float64 {
+	if f.Runs == 0 {
+		return 0
+	}
+	return float64(f.Flaky+f.Flips) / float64(f.Runs)
+}
+
+// Flakiness analyzes the runs of each spec, returning the specs that were
+// ever flaky or flipped, most flaky first.
+func Flakiness(runs []HistoryRun) []SpecFlakiness {
+	bySpec := map[string]*SpecFlakiness{}
+	var order []string
+	lastFailed := map[string]bool{}
+	for _, run := range runs {
+		for _, spec := range run.Specs {
+			if !spec.ran() {
+				continue
+			}
+			flakiness, ok := bySpec[spec.ID]
+			if !ok {
+				flakiness = &SpecFlakiness{ID: spec.ID}
+				bySpec[spec.ID] = flakiness
+				order = append(order, spec.ID)
+			} else if lastFailed[spec.ID] != spec.failed() {
+				flakiness.Flips++
+			}
+			flakiness.Name = spec.Name
+			flakiness.Location = spec.Location
+			flakiness.Runs++
+			if spec.failed() {
+				flakiness.Failures++
+			}
+			if spec.Flaky {
+				flakiness.Flaky++
+			}
+			lastFailed[spec.ID] = spec.failed()
+		}
+	}
+
+	var result []SpecFlakiness
+	for _, id := range order {
+		if flakiness := bySpec[id]; flakiness.Flaky+flakiness.Flips > 0 {
+			result = append(result, *flakiness)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Rate() > result[j].Rate()
+	})
+	return result
+}
+
+// SpecDurationTrend is how a spec's duration changed over its runs.
+type SpecDurationTrend struct {
+	ID        string
+	Name      string
+	Location  string
+	Durations []time.Duration
+}
+
+// Mean is the average of the spec's durations.
+func (t SpecDurationTrend) Mean() time.Duration {
+	var total time.Duration
+	for _, duration := range t.Durations {
+		total += duration
+	}
+	return total / time.Duration(len(t.Durations))
+}
+
+// Change is the relative change of the last duration from the mean of the
+// earlier ones, e.g. 0.5 for a spec that got 50% slower.
+func (t SpecDurationTrend) Change() float64 {
+	if len(t.Durations) < 2 {
+		return 0
+	}
+	earlier := SpecDurationTrend{Durations: t.Durations[:len(t.Durations)-1]}.Mean()
+	if earlier == 0 {
+		return 0
+	}
+	return float64(t.Durations[len(t.Durations)-1]-earlier) / float64(earlier)
+}
+
+// DurationTrends collects the durations of each spec that ran, in the order
+// of the runs, with the specs that slowed down the most first.
+func DurationTrends(runs []HistoryRun) []SpecDurationTrend {
+	bySpec := map[string]*SpecDurationTrend{}
+	var order []string
+	for _, run := range runs {
+		for _, spec := range run.Specs {
+			if !spec.ran() {
+				continue
+			}
+			trend, ok := bySpec[spec.ID]
+			if !ok {
+				trend = &SpecDurationTrend{ID: spec.ID}
+				bySpec[spec.ID] = trend
+				order = append(order, spec.ID)
+			}
+			trend.Name = spec.Name
+			trend.Location = spec.Location
+			trend.Durations = append(trend.Durations, spec.RunTime)
+		}
+	}
+
+	result := make([]SpecDurationTrend, len(order))
+	for i, id := range order {
+		result[i] = *bySpec[id]
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Change() > result[j].Change()
+	})
+	return result
+}
+
+// NewlyFailing returns the specs that failed in the last run of their suite
+// but not in the run before it.
+func NewlyFailing(runs []HistoryRun) []HistorySpec {
+	last := map[string]int{}
+	previous := map[string]int{}
+	var suites []string
+	for i, run := range runs {
+		if j, ok := last[run.Suite]; ok {
+			previous[run.Suite] = j
+		} else {
+			suites = append(suites, run.Suite)
+		}
+		last[run.Suite] = i
+	}
+
+	var failing []HistorySpec
+	for _, suite := range suites {
+		failedBefore := map[string]bool{}
+		if j, ok := previous[suite]; ok {
+			for _, spec := range runs[j].Specs {
+				failedBefore[spec.ID] = spec.failed()
+			}
+		}
+		for _, spec := range runs[last[suite]].Specs {
+			if spec.failed() && !failedBefore[spec.ID] {
+				failing = append(failing, spec)
+			}
+		}
+	}
+	return failing
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(historyReporter)
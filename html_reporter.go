@@ -0,0 +1,121 @@
+package biloba
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+type htmlReporter struct {
+	*reportCollector
+	filename string
+}
+
+// NewHTMLReporter writes a single, self-contained HTML page to filename once
+// the suite ends. The page shows the specs as a collapsible tree of their
+// containers, with filters by state, a search box, and each spec's duration,
+// failure and captured output.
+func NewHTMLReporter(filename string) *htmlReporter {
+	r := &htmlReporter{filename: filename}
+	r.reportCollector = newReportCollector(r.writeReport)
+	return r
+}
+
+func (r *htmlReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.finish(summary)
+}
+
+func (r *htmlReporter) writeReport(report Report) {
+	var page bytes.Buffer
+	err := htmlTemplate.Execute(&page, newHTMLPage(report))
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.filename), 0755)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(r.filename, page.Bytes(), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write HTML report: %s\n", err)
+	}
+}
+
+type htmlPage struct {
+	Report  Report
+	Tree    *htmlNode
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+// htmlNode is a container of the tree, or a spec when Spec is set.
+type htmlNode struct {
+	Text     string
+	Spec     *SpecReport
+	Children []*htmlNode
+
+	index map[string]*htmlNode
+}
+
+func newHTMLPage(report Report) htmlPage {
+	page := htmlPage{Report: report, Tree: &htmlNode{}}
+	for i := range report.Specs {
+		spec := &report.Specs[i]
+		switch htmlState(spec) {
+		case "passed":
+			page.Passed++
+		case "failed":
+			page.Failed++
+		default:
+			page.Skipped++
+		}
+
+		node := page.Tree
+		for _, text := range spec.ComponentTexts[:len(spec.ComponentTexts)-1] {
+			node = node.child(text)
+		}
+		node.Children = append(node.Children, &htmlNode{Text: spec.ComponentTexts[len(spec.ComponentTexts)-1], Spec: spec})
+	}
+	return page
+}
+
+func (n *htmlNode) child(text string) *htmlNode {
+	if n.index == nil {
+		n.index = map[string]*htmlNode{}
+	}
+	if child, ok := n.index[text]; ok {
+		return child
+	}
+	child := &htmlNode{Text: text}
+	n.index[text] = child
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// htmlState groups spec states into the three the page filters by.
+func htmlState(spec *SpecReport) string {
+	switch spec.State {
+	case stateName(types.SpecStatePassed):
+		return "passed"
+	case stateName(types.SpecStateSkipped), stateName(types.SpecStatePending):
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"state": htmlState,
+	"duration": func(duration time.Duration) string {
+		return fmt.Sprintf("%.3fs", duration.Seconds())
+	},
+}).Parse(htmlPageTemplate))
+
+// force compatibility
+var _ ginkgo.Reporter = new(htmlReporter)
@@ -0,0 +1,97 @@
+package biloba
+
+// htmlPageTemplate renders an htmlPage. Its styles and scripts are inline so
+// that the page works offline, e.g. when opened from a CI artifact.
+const htmlPageTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Suite}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { font-size: 1.5em; margin-bottom: 0.2em; }
+.summary { margin-bottom: 1em; }
+.summary span { margin-right: 1em; }
+.controls { position: sticky; top: 0; background: #fff; padding: 0.5em 0; border-bottom: 1px solid #e1e4e8; }
+.controls label { margin-right: 1em; }
+.controls input[type=search] { width: 20em; }
+details { margin-left: 1.2em; }
+summary { cursor: pointer; padding: 0.1em 0; }
+.spec { margin-left: 1.2em; padding: 0.1em 0; }
+.spec > summary { list-style: none; }
+.state { display: inline-block; width: 4.5em; font-weight: bold; font-size: 0.8em; }
+.passed > summary .state, .passed .state { color: #22863a; }
+.failed > summary .state, .failed .state { color: #cb2431; }
+.skipped > summary .state, .skipped .state { color: #6a737d; }
+.duration, .location { color: #6a737d; font-size: 0.85em; margin-left: 0.5em; }
+.annotation { font-size: 0.8em; background: #fff5b1; padding: 0 0.3em; margin-left: 0.5em; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; margin: 0.3em 0 0.6em 1.2em; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Suite}}</h1>
+<div class="summary">
+<span>{{if .Report.Succeeded}}<strong class="passed">Succeeded</strong>{{else}}<strong class="failed">Failed</strong>{{end}}</span>
+<span>{{len .Report.Specs}} specs in {{duration .Report.RunTime}}</span>
+<span>{{.Passed}} passed</span>
+<span>{{.Failed}} failed</span>
+<span>{{.Skipped}} skipped</span>
+</div>
+<div class="controls">
+<label><input type="checkbox" class="filter" value="passed" checked> Passed</label>
+<label><input type="checkbox" class="filter" value="failed" checked> Failed</label>
+<label><input type="checkbox" class="filter" value="skipped" checked> Skipped</label>
+<input type="search" id="search" placeholder="Search specs">
+</div>
+<div id="tree">
+{{- range .Tree.Children}}{{template "node" .}}{{end}}
+</div>
+<script>
+(function () {
+  var specs = document.querySelectorAll(".spec");
+  var containers = Array.prototype.slice.call(document.querySelectorAll(".container")).reverse();
+  function update() {
+    var states = {};
+    document.querySelectorAll(".filter").forEach(function (filter) { states[filter.value] = filter.checked; });
+    var query = document.getElementById("search").value.toLowerCase();
+    specs.forEach(function (spec) {
+      var visible = states[spec.dataset.state] && spec.dataset.name.toLowerCase().indexOf(query) !== -1;
+      spec.classList.toggle("hidden", !visible);
+    });
+    containers.forEach(function (container) {
+      container.classList.toggle("hidden", container.querySelector(".spec:not(.hidden)") === null);
+    });
+  }
+  document.querySelectorAll(".filter").forEach(function (filter) { filter.addEventListener("change", update); });
+  document.getElementById("search").addEventListener("input", update);
+})();
+</script>
+</body>
+</html>
+{{define "node"}}
+{{- if .Spec}}{{with .Spec}}
+<details class="spec {{state .}}" data-state="{{state .}}" data-name="{{.Name}}"{{if eq (state .) "failed"}} open{{end}}>
+<summary><span class="state">{{state .}}</span>{{$.Text}}<span class="duration">{{duration .RunTime}}</span>
+{{- if .Flaky}}<span class="annotation">flaky</span>{{end}}
+{{- if .Quarantine}}<span class="annotation">quarantined</span>{{end}}
+{{- if .ExpectedFailure}}<span class="annotation">expected failure</span>{{end}}
+{{- if .UnexpectedPass}}<span class="annotation">unexpected pass</span>{{end}}
+{{- if .Slow}}<span class="annotation">slow</span>{{end}}</summary>
+<div class="location">{{.Location}}</div>
+{{- if .Failure}}
+<pre class="failure">{{.Failure}}
+{{.FailureLocation}}</pre>
+{{- end}}
+{{- if .Output}}
+<pre class="output">{{.Output}}</pre>
+{{- end}}
+</details>
+{{- end}}{{else}}
+<details class="container" open>
+<summary>{{.Text}}</summary>
+{{- range .Children}}{{template "node" .}}{{end}}
+</details>
+{{- end}}
+{{- end}}
+`
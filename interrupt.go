@@ -0,0 +1,125 @@
+package biloba
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// timeoutGrace is how long before the go test -timeout deadline the abort
+// hooks run, leaving time to report before the testing package panics.
+const timeoutGrace = time.Second
+
+var (
+	processStart = time.Now()
+	aborts       = &abortWatcher{hooks: map[int]func(string){}}
+)
+
+// abortWatcher runs the registered hooks when the test process is about to
+// die without ginkgo finishing the suite: on SIGINT/SIGTERM, or shortly
+// before the go test -timeout deadline.
+type abortWatcher struct {
+	mu      sync.Mutex
+	hooks   map[int]func(reason string)
+	nextID  int
+	signals chan os.Signal
+	timer   *time.Timer
+}
+
+// register adds a hook and returns a function that removes it again.
+func (w *abortWatcher) register(hook func(reason string)) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.hooks) == 0 {
+		w.start()
+	}
+	id := w.nextID
+	w.nextID++
+	w.hooks[id] = hook
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.hooks, id)
+		if len(w.hooks) == 0 {
+			w.stop()
+		}
+	}
+}
+
+func (w *abortWatcher) start() {
+	w.signals = make(chan os.Signal, 1)
+	signal.Notify(w.signals, os.Interrupt, syscall.SIGTERM)
+	go func(signals chan os.Signal) {
+		if _, ok := <-signals; ok {
+			w.abort("interrupted")
+		}
+	}(w.signals)
+
+	if timeout := testTimeout(); timeout > 0 {
+		deadline := processStart.Add(timeout - timeoutGrace)
+		w.timer = time.AfterFunc(time.Until(deadline), func() {
+			w.abort("timed out")
+		})
+	}
+}
+
+func (w *abortWatcher) stop() {
+	signal.Stop(w.signals)
+	close(w.signals)
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+func (w *abortWatcher) abort(reason string) {
+	w.mu.Lock()
+	hooks := make([]func(string), 0, len(w.hooks))
+	for id := 0; id < w.nextID; id++ {
+		if hook, ok := w.hooks[id]; ok {
+			hooks = append(hooks, hook)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(reason)
+	}
+	os.Stdout.Sync()
+}
+
+func testTimeout() time.Duration {
+	timeoutFlag := flag.Lookup("test.timeout")
+	if timeoutFlag == nil {
+		return 0
+	}
+	getter, ok := timeoutFlag.Value.(flag.Getter)
+	if !ok {
+		return 0
+	}
+	timeout, _ := getter.Get().(time.Duration)
+	return timeout
+}
+
+func goroutineDump() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func printGoroutineDump(out io.Writer, reason string) {
+	fmt.Fprintf(out, "\nSpec %s. Goroutine dump:\n\n%s\n", reason, goroutineDump())
+}
@@ -0,0 +1,34 @@
+package biloba
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+type jsonReporter struct {
+	*reportCollector
+	filename string
+}
+
+// NewJSONReporter writes a Report for the suite to filename once the suite ends.
+func NewJSONReporter(filename string) *jsonReporter {
+	r := &jsonReporter{filename: filename}
+	r.reportCollector = newReportCollector(r.writeReport)
+	return r
+}
+
+func (r *jsonReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.finish(summary)
+}
+
+func (r *jsonReporter) writeReport(report Report) {
+	if err := writeJSON(r.filename, report); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write JSON report: %s\n", err)
+	}
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(jsonReporter)
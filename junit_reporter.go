@@ -0,0 +1,147 @@
+package biloba
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       float64          `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitMessage    `xml:"failure,omitempty"`
+	Skipped    *junitMessage    `xml:"skipped,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Type    string `xml:"type,attr,omitempty"`
+	Message string `xml:"message,attr,omitempty"`
+	Details string `xml:",chardata"`
+}
+
+type junitReporter struct {
+	*reportCollector
+	filename string
+}
+
+// NewJUnitReporter writes a JUnit XML report to filename once the suite ends.
+// Unlike ginkgo's JUnit reporter it reports each spec once, however many
+// times it was retried, and records biloba's annotations (flaky, quarantined)
+// as testcase properties.
+func NewJUnitReporter(filename string) *junitReporter {
+	r := &junitReporter{filename: filename}
+	r.reportCollector = newReportCollector(r.writeReport)
+	return r
+}
+
+func (r *junitReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.finish(summary)
+}
+
+func (r *junitReporter) writeReport(report Report) {
+	data, err := xml.MarshalIndent(junitSuite(report), "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.filename), 0755)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(r.filename, append([]byte(xml.Header), append(data, '\n')...), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write JUnit report: %s\n", err)
+	}
+}
+
+func junitSuite(report Report) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      report.Suite,
+		Time:      report.RunTime.Seconds(),
+		TestCases: []junitTestCase{},
+	}
+	for _, spec := range report.Specs {
+		testCase := junitTestCase{
+			Name:      spec.Name,
+			ClassName: report.Suite,
+			Time:      spec.RunTime.Seconds(),
+		}
+
+		properties := []junitProperty{{Name: "id", Value: spec.ID}, {Name: "location", Value: spec.Location}}
+		if spec.Attempts > 1 {
+			properties = append(properties, junitProperty{Name: "attempts", Value: strconv.Itoa(spec.Attempts)})
+		}
+		if spec.Flaky {
+			properties = append(properties, junitProperty{Name: "flaky", Value: "true"})
+		}
+		if spec.Quarantine != nil {
+			properties = append(properties,
+				junitProperty{Name: "quarantined", Value: "true"},
+				junitProperty{Name: "quarantine.owner", Value: spec.Quarantine.Owner},
+				junitProperty{Name: "quarantine.ticket", Value: spec.Quarantine.Ticket},
+			)
+		}
+		if spec.ExpectedFailure {
+			properties = append(properties, junitProperty{Name: "xfail", Value: "expected failure"})
+		}
+		if spec.UnexpectedPass {
+			properties = append(properties, junitProperty{Name: "xfail", Value: "unexpected pass"})
+		}
+		testCase.Properties = &junitProperties{Properties: properties}
+
+		switch {
+		case spec.Quarantine != nil && spec.State == stateName(types.SpecStateSkipped):
+			testCase.Skipped = &junitMessage{
+				Message: "quarantined failure",
+				Details: spec.Failure + "\n" + spec.FailureLocation,
+			}
+			testCase.SystemOut = spec.Output
+			suite.Skipped++
+		case spec.ExpectedFailure:
+			testCase.SystemOut = spec.Output
+		case spec.Failure != "":
+			testCase.Failure = &junitMessage{
+				Type:    spec.State,
+				Message: spec.State,
+				Details: spec.Failure + "\n" + spec.FailureLocation,
+			}
+			testCase.SystemOut = spec.Output
+			suite.Failures++
+		case spec.State == stateName(types.SpecStateSkipped) || spec.State == stateName(types.SpecStatePending):
+			testCase.Skipped = &junitMessage{Message: spec.State}
+			suite.Skipped++
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+	}
+	return suite
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(junitReporter)
@@ -0,0 +1,174 @@
+package biloba
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo"
+)
+
+// leakSettleTime is how long goroutines started by a spec get to exit once it
+// ends before they are reported as leaked.
+const leakSettleTime = 100 * time.Millisecond
+
+// defaultLeakIgnores are prefixes of the functions that start goroutines owned
+// by the runtime, go test, ginkgo and biloba itself.
+var defaultLeakIgnores = []string{
+	"runtime.",
+	"testing.",
+	"os/signal.",
+	"github.com/onsi/ginkgo/",
+	"github.com/matt-royal/biloba.",
+}
+
+var leaks = &leakDetector{}
+
+// DetectGoroutineLeaks turns on goroutine leak detection. Goroutines started
+// while a spec runs that are still alive once it ends are printed in the
+// spec's go test output. ignore lists prefixes of function names, such as
+// "github.com/lib/pq.", of goroutines that shouldn't count as leaks: those
+// with any of them on their stack are left out.
+//
+// Call it before RunSpecs; leaks are detected by the reporter from
+// NewGoTestCompatibleReporter. To fail specs that leak goroutines, also add
+//
+//	AfterEach(biloba.VerifyNoGoroutineLeaks)
+func DetectGoroutineLeaks(ignore ...string) {
+	leaks.mu.Lock()
+	defer leaks.mu.Unlock()
+
+	leaks.enabled = true
+	leaks.ignore = ignore
+}
+
+// VerifyNoGoroutineLeaks fails the current spec if goroutines it started are
+// still running. It is meant to be used as an AfterEach once leak detection
+// is turned on with DetectGoroutineLeaks.
+func VerifyNoGoroutineLeaks() {
+	if leaked := leaks.leaked(); len(leaked) > 0 {
+		ginkgo.Fail(leakMessage(leaked))
+	}
+}
+
+type leakDetector struct {
+	mu      sync.Mutex
+	enabled bool
+	ignore  []string
+	before  map[string]bool
+}
+
+// snapshot records the goroutines running before a spec starts.
+func (d *leakDetector) snapshot() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.enabled {
+		return
+	}
+
+	d.before = map[string]bool{}
+	for _, g := range runningGoroutines() {
+		d.before[g.id] = true
+	}
+}
+
+// leaked returns the goroutines started since the last snapshot that didn't
+// exit within leakSettleTime.
+func (d *leakDetector) leaked() []goroutine {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.enabled || d.before == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(leakSettleTime)
+	for {
+		var leaked []goroutine
+		for _, g := range runningGoroutines() {
+			if !d.before[g.id] && !d.ignored(g) {
+				leaked = append(leaked, g)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (d *leakDetector) ignored(g goroutine) bool {
+	for _, prefix := range defaultLeakIgnores {
+		if strings.HasPrefix(g.entry(), prefix) || strings.HasPrefix(g.createdBy, prefix) {
+			return true
+		}
+	}
+	for _, prefix := range d.ignore {
+		for _, function := range g.functions {
+			if strings.HasPrefix(function, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type goroutine struct {
+	id        string
+	functions []string
+	createdBy string
+	stack     string
+}
+
+// entry is the function the goroutine was started with.
+func (g goroutine) entry() string {
+	if len(g.functions) == 0 {
+		return ""
+	}
+	return g.functions[len(g.functions)-1]
+}
+
+// runningGoroutines parses a dump of all goroutines, which lists each one as
+// a "goroutine N [state]:" header followed by its frames, innermost first,
+// and a "created by" line.
+func runningGoroutines() []goroutine {
+	var goroutines []goroutine
+	for _, stack := range strings.Split(strings.TrimSpace(goroutineDump()), "\n\n") {
+		lines := strings.Split(stack, "\n")
+		header := strings.Fields(lines[0])
+		if len(header) < 2 || header[0] != "goroutine" {
+			continue
+		}
+
+		g := goroutine{id: header[1], stack: stack}
+		for _, line := range lines[1:] {
+			switch {
+			case strings.HasPrefix(line, "created by "):
+				g.createdBy = strings.SplitN(strings.TrimPrefix(line, "created by "), " in goroutine ", 2)[0]
+			case strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "...") || g.createdBy != "":
+			case strings.HasPrefix(line, "runtime.goexit("):
+			default:
+				g.functions = append(g.functions, frameFunction(line))
+			}
+		}
+		goroutines = append(goroutines, g)
+	}
+	return goroutines
+}
+
+// frameFunction strips the arguments from a frame such as
+// "main.(*server).serve(0xc000010000, ...)".
+func frameFunction(line string) string {
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func leakMessage(leaked []goroutine) string {
+	stacks := make([]string, len(leaked))
+	for i, g := range leaked {
+		stacks[i] = g.stack
+	}
+	return fmt.Sprintf("%d goroutine(s) started by the spec are still running:\n\n%s", len(leaked), strings.Join(stacks, "\n\n"))
+}
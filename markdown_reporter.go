@@ -0,0 +1,121 @@
+package biloba
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+type markdownReporter struct {
+	*reportCollector
+	filename string
+}
+
+// NewMarkdownReporter writes a summary of the suite in Markdown to filename
+// once the suite ends, for pull requests and CI job summaries: a table of
+// totals, the failed specs with their failures in collapsible blocks, and the
+// slowest specs.
+func NewMarkdownReporter(filename string) *markdownReporter {
+	r := &markdownReporter{filename: filename}
+	r.reportCollector = newReportCollector(r.writeReport)
+	return r
+}
+
+func (r *markdownReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.finish(summary)
+}
+
+func (r *markdownReporter) writeReport(report Report) {
+	err := os.MkdirAll(filepath.Dir(r.filename), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(r.filename, markdownSummary(report), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write Markdown report: %s\n", err)
+	}
+}
+
+func markdownSummary(report Report) []byte {
+	var out bytes.Buffer
+	result := "Succeeded"
+	if !report.Succeeded {
+		result = "Failed"
+	}
+	fmt.Fprintf(&out, "# %s\n\n**%s** in %.3fs\n\n", report.Suite, result, report.RunTime.Seconds())
+
+	counts := map[string]int{}
+	flaky := 0
+	var failed []SpecReport
+	for _, spec := range report.Specs {
+		counts[spec.State]++
+		if spec.Flaky {
+			flaky++
+		}
+		if spec.Failure != "" && !spec.ExpectedFailure && spec.Quarantine == nil {
+			failed = append(failed, spec)
+		}
+	}
+	failures := counts[stateName(types.SpecStateFailed)] + counts[stateName(types.SpecStatePanicked)] +
+		counts[stateName(types.SpecStateTimedOut)] + counts[stateName(types.SpecStateInvalid)]
+	fmt.Fprintf(&out, "| Passed | Failed | Skipped | Pending | Flaky | Total |\n")
+	fmt.Fprintf(&out, "| ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&out, "| %d | %d | %d | %d | %d | %d |\n",
+		counts[stateName(types.SpecStatePassed)], failures, counts[stateName(types.SpecStateSkipped)],
+		counts[stateName(types.SpecStatePending)], flaky, len(report.Specs))
+
+	if len(failed) > 0 {
+		fmt.Fprintf(&out, "\n## Failed specs\n")
+		for _, spec := range failed {
+			fence := markdownFence(spec.Failure)
+			fmt.Fprintf(&out, "\n<details>\n<summary><code>%s</code></summary>\n\n%s\n%s\n%s\n%s\n\n</details>\n",
+				htmlEscape(spec.Name), fence, spec.Failure, spec.FailureLocation, fence)
+		}
+	}
+
+	var slowest []SpecReport
+	for _, spec := range report.Specs {
+		if spec.State != stateName(types.SpecStateSkipped) && spec.State != stateName(types.SpecStatePending) {
+			slowest = append(slowest, spec)
+		}
+	}
+	sort.SliceStable(slowest, func(i, j int) bool {
+		return slowest[i].RunTime > slowest[j].RunTime
+	})
+	if len(slowest) > slowestSpecsCount {
+		slowest = slowest[:slowestSpecsCount]
+	}
+	if len(slowest) > 0 {
+		fmt.Fprintf(&out, "\n## Slowest specs\n\n| Duration | Spec | Location |\n| ---: | --- | --- |\n")
+		for _, spec := range slowest {
+			fmt.Fprintf(&out, "| %.3fs | %s | %s |\n", spec.RunTime.Seconds(), markdownCell(spec.Name), markdownCell(spec.Location))
+		}
+	}
+	return out.Bytes()
+}
+
+// markdownFence returns a code fence longer than any run of backticks in text.
+func markdownFence(text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence
+}
+
+func markdownCell(text string) string {
+	return strings.Replace(htmlEscape(text), "|", `\|`, -1)
+}
+
+func htmlEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(markdownReporter)
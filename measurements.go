@@ -0,0 +1,99 @@
+package biloba
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+// benchmarkLines renders the measurements of a ginkgo Measure spec as go
+// benchmark result lines, one per measurement, so that the output can be fed
+// into benchstat. Each result line is followed by a "--- BENCH:" block holding
+// the remaining statistics, which test2json reports as a bench action.
+func benchmarkLines(suiteDescription string, spec *types.SpecSummary) []string {
+	var lines []string
+	for _, measurement := range sortedMeasurements(spec) {
+		name := benchmarkName(suiteDescription, spec, measurement)
+		value, unit := benchmarkMetric(measurement)
+		lines = append(lines,
+			fmt.Sprintf("%s\t%8d\t%s %s", name, len(measurement.Results), formatMetric(value), unit),
+			fmt.Sprintf("--- BENCH: %s", name),
+			fmt.Sprintf("    smallest: %s %s, largest: %s %s, stddev: %s %s",
+				formatMetric(scaleMetric(measurement, measurement.Smallest)), unit,
+				formatMetric(scaleMetric(measurement, measurement.Largest)), unit,
+				formatMetric(scaleMetric(measurement, measurement.StdDeviation)), unit,
+			),
+		)
+	}
+	return lines
+}
+
+func sortedMeasurements(spec *types.SpecSummary) []*types.SpecMeasurement {
+	measurements := make([]*types.SpecMeasurement, 0, len(spec.Measurements))
+	for _, measurement := range spec.Measurements {
+		measurements = append(measurements, measurement)
+	}
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].Order < measurements[j].Order
+	})
+	return measurements
+}
+
+// benchmarkName follows the naming go test uses for sub-benchmarks:
+// BenchmarkSuite/level_1/measures_x/runtime
+func benchmarkName(suiteDescription string, spec *types.SpecSummary, measurement *types.SpecMeasurement) string {
+	parts := []string{"Benchmark" + camelCase(suiteDescription)}
+	for _, text := range spec.ComponentTexts[1:] {
+		parts = append(parts, benchmarkSegment(text))
+	}
+	parts = append(parts, benchmarkSegment(measurement.Name))
+	return strings.Join(parts, "/")
+}
+
+// benchmarkMetric converts ginkgo's timings (recorded in seconds) to ns/op, and
+// keeps the units of any other measurement, defaulting to the measurement name.
+func benchmarkMetric(measurement *types.SpecMeasurement) (float64, string) {
+	value := scaleMetric(measurement, measurement.Average)
+	switch measurement.Units {
+	case "s":
+		return value, "ns/op"
+	case "":
+		return value, benchmarkUnit(measurement.Name)
+	default:
+		return value, benchmarkUnit(measurement.Units)
+	}
+}
+
+func scaleMetric(measurement *types.SpecMeasurement, value float64) float64 {
+	if measurement.Units == "s" {
+		return value * 1e9
+	}
+	return value
+}
+
+func formatMetric(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.4f", value), "0"), ".")
+}
+
+func camelCase(text string) string {
+	var builder strings.Builder
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+	return builder.String()
+}
+
+func benchmarkSegment(text string) string {
+	return strings.Join(strings.Fields(text), "_")
+}
+
+// benchmarkUnit strips whitespace, which benchstat would otherwise read as
+// the start of the next value/unit pair.
+func benchmarkUnit(units string) string {
+	return strings.Join(strings.Fields(units), "-")
+}
@@ -0,0 +1,192 @@
+package biloba
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+)
+
+// parallelWaitTimeout bounds how long nodes wait for each other: node 1 for
+// the other nodes to finish, and the other nodes for node 1 to start listening.
+const parallelWaitTimeout = 10 * time.Second
+
+// blockSink receives the complete output block of each spec when running in
+// parallel, so that blocks from different nodes are never interleaved.
+type blockSink interface {
+	send(block string)
+	close()
+}
+
+type parallelMessage struct {
+	Node  int    `json:"node"`
+	Block string `json:"block,omitempty"`
+	End   bool   `json:"end,omitempty"`
+}
+
+// newBlockSink returns nil when the suite isn't running in parallel. Node 1
+// prints the blocks of every node; the other nodes forward theirs to it.
+func newBlockSink(ginkgoConfig config.GinkgoConfigType, out io.Writer) blockSink {
+	if ginkgoConfig.ParallelTotal <= 1 {
+		return nil
+	}
+
+	addrFile := parallelAddrFile(ginkgoConfig)
+	if ginkgoConfig.ParallelNode == 1 {
+		collector, err := newParallelCollector(addrFile, ginkgoConfig.ParallelTotal, out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "biloba: failed to collect output from parallel nodes: %s\n", err)
+			return &writerSink{out: out}
+		}
+		return collector
+	}
+	return newParallelForwarder(addrFile, ginkgoConfig.ParallelNode, out)
+}
+
+// parallelAddrFile is where node 1 publishes its address. Every node of a run
+// derives the same path from the shared parallel configuration.
+func parallelAddrFile(ginkgoConfig config.GinkgoConfigType) string {
+	dir, _ := os.Getwd()
+	key := fmt.Sprintf("%s|%s|%d", dir, ginkgoConfig.SyncHost, ginkgoConfig.ParallelTotal)
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(os.TempDir(), "biloba-parallel-"+hex.EncodeToString(sum[:])[:12])
+}
+
+type writerSink struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (s *writerSink) send(block string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(s.out, block)
+}
+
+func (s *writerSink) close() {}
+
+type parallelCollector struct {
+	writerSink
+	listener net.Listener
+	addrFile string
+	pending  sync.WaitGroup
+}
+
+func newParallelCollector(addrFile string, total int, out io.Writer) (*parallelCollector, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(addrFile, []byte(listener.Addr().String()), 0644); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	collector := &parallelCollector{
+		writerSink: writerSink{out: out},
+		listener:   listener,
+		addrFile:   addrFile,
+	}
+	collector.pending.Add(total - 1)
+	go collector.accept()
+	return collector, nil
+}
+
+func (c *parallelCollector) accept() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go c.receive(conn)
+	}
+}
+
+func (c *parallelCollector) receive(conn net.Conn) {
+	defer conn.Close()
+	decoder := json.NewDecoder(conn)
+	for {
+		var message parallelMessage
+		if err := decoder.Decode(&message); err != nil {
+			return
+		}
+		if message.End {
+			c.pending.Done()
+			return
+		}
+		c.send(message.Block)
+	}
+}
+
+// close waits for the other nodes to finish, so that their last blocks are
+// printed before node 1's suite ends.
+func (c *parallelCollector) close() {
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(parallelWaitTimeout):
+		fmt.Fprintf(os.Stderr, "biloba: timed out waiting for parallel nodes to finish\n")
+	}
+	c.listener.Close()
+	os.Remove(c.addrFile)
+}
+
+type parallelForwarder struct {
+	node     int
+	addrFile string
+	fallback io.Writer
+
+	once    sync.Once
+	conn    net.Conn
+	encoder *json.Encoder
+}
+
+func newParallelForwarder(addrFile string, node int, fallback io.Writer) *parallelForwarder {
+	return &parallelForwarder{node: node, addrFile: addrFile, fallback: fallback}
+}
+
+// connect dials node 1, retrying until it has published its address.
+func (f *parallelForwarder) connect() {
+	deadline := time.Now().Add(parallelWaitTimeout)
+	for time.Now().Before(deadline) {
+		if addr, err := ioutil.ReadFile(f.addrFile); err == nil {
+			if conn, err := net.Dial("tcp", strings.TrimSpace(string(addr))); err == nil {
+				f.conn = conn
+				f.encoder = json.NewEncoder(conn)
+				return
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	fmt.Fprintf(os.Stderr, "biloba: failed to reach parallel node 1, printing output locally\n")
+}
+
+func (f *parallelForwarder) send(block string) {
+	f.once.Do(f.connect)
+	if f.encoder == nil || f.encoder.Encode(parallelMessage{Node: f.node, Block: block}) != nil {
+		io.WriteString(f.fallback, block)
+	}
+}
+
+func (f *parallelForwarder) close() {
+	f.once.Do(f.connect)
+	if f.encoder == nil {
+		return
+	}
+	f.encoder.Encode(parallelMessage{Node: f.node, End: true})
+	f.conn.Close()
+}
@@ -0,0 +1,139 @@
+package biloba
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+// SpecProfiles are the files profiles of a slow spec were written to.
+type SpecProfiles struct {
+	CPU  string `json:"cpu"`
+	Heap string `json:"heap"`
+}
+
+var profiles = &profiler{}
+
+// ProfileSlowSpecs captures a CPU profile of every spec and keeps it, along
+// with a heap profile taken when the spec ends, for the specs that run longer
+// than threshold. The profiles are written to dir as <spec id>.cpu.pprof and
+// <spec id>.heap.pprof, and referenced from the spec's go test output and the
+// JSON reports. Call it before RunSpecs.
+func ProfileSlowSpecs(threshold time.Duration, dir string) {
+	profiles.mu.Lock()
+	defer profiles.mu.Unlock()
+
+	profiles.enabled = true
+	profiles.threshold = threshold
+	profiles.dir = dir
+}
+
+// profiler profiles specs on behalf of every reporter, like resourceCollector.
+type profiler struct {
+	mu        sync.Mutex
+	enabled   bool
+	threshold time.Duration
+	dir       string
+
+	current  string
+	cpuFile  *os.File
+	since    time.Time
+	finished map[string]*SpecProfiles
+}
+
+func (p *profiler) start(spec *types.SpecSummary) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.enabled || p.cpuFile != nil {
+		return
+	}
+
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		p.disable(err)
+		return
+	}
+	file, err := ioutil.TempFile(p.dir, "cpu-")
+	if err != nil {
+		p.disable(err)
+		return
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		p.disable(err)
+		return
+	}
+
+	key := specKey(spec)
+	delete(p.finished, key)
+	p.current = key
+	p.cpuFile = file
+	p.since = time.Now()
+}
+
+// profiles returns the profiles of a spec that completed, or nil when it
+// wasn't slow or isn't profiled.
+func (p *profiler) profiles(spec *types.SpecSummary) *SpecProfiles {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := specKey(spec)
+	if p.cpuFile == nil || p.current != key {
+		return p.finished[key]
+	}
+
+	pprof.StopCPUProfile()
+	p.cpuFile.Close()
+	cpuFile := p.cpuFile.Name()
+	p.cpuFile = nil
+	if p.finished == nil {
+		p.finished = map[string]*SpecProfiles{}
+	}
+	p.finished[key] = nil
+
+	if time.Since(p.since) <= p.threshold {
+		os.Remove(cpuFile)
+		return nil
+	}
+
+	id := SpecID(spec)
+	specProfiles := &SpecProfiles{
+		CPU:  filepath.Join(p.dir, id+".cpu.pprof"),
+		Heap: filepath.Join(p.dir, id+".heap.pprof"),
+	}
+	if err := os.Rename(cpuFile, specProfiles.CPU); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write CPU profile: %s\n", err)
+		return nil
+	}
+	if err := writeHeapProfile(specProfiles.Heap); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write heap profile: %s\n", err)
+		return nil
+	}
+	p.finished[key] = specProfiles
+	return specProfiles
+}
+
+// disable stops profiling, e.g. when go test already runs with -cpuprofile.
+func (p *profiler) disable(err error) {
+	p.enabled = false
+	fmt.Fprintf(os.Stderr, "biloba: not profiling slow specs: %s\n", err)
+}
+
+func writeHeapProfile(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// the heap profile is only as current as the last garbage collection
+	runtime.GC()
+	return pprof.WriteHeapProfile(file)
+}
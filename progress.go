@@ -0,0 +1,241 @@
+package biloba
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// DefaultDurationsFile is where the progress reporter keeps the durations of
+// the last run of each spec, relative to the package under test, to estimate
+// how long the next run will take.
+const DefaultDurationsFile = ".biloba/durations.json"
+
+// progressRedrawInterval is how often the status line is redrawn on a
+// terminal while a spec runs, to keep the elapsed time and estimate current.
+const progressRedrawInterval = time.Second
+
+// SpecDurations maps spec IDs to how long the specs took in their last run.
+type SpecDurations map[string]time.Duration
+
+type progressReporter struct {
+	mu            sync.Mutex
+	config        config.GinkgoConfigType
+	out           io.Writer
+	tty           bool
+	durationsFile string
+	history       SpecDurations
+	durations     SpecDurations
+	attempts      attemptCounter
+	stopRedraw    chan struct{}
+
+	total   int
+	done    int
+	failed  int
+	started time.Time
+	running string
+	// unseen holds the previous durations of the specs that ginkgo hasn't
+	// reported yet in this run, adding up to remaining
+	unseen    SpecDurations
+	seen      map[string]bool
+	remaining time.Duration
+}
+
+// NewProgressReporter shows the progress of the suite on stderr: specs done
+// out of the total, failures so far, the running spec, the elapsed time and
+// an estimate of the time left, based on the durations recorded in
+// durationsFile by the previous run. On a terminal it keeps updating a single
+// status line; otherwise it prints a line whenever a spec completes.
+func NewProgressReporter(durationsFile string) *progressReporter {
+	return &progressReporter{
+		out:           os.Stderr,
+		tty:           isTerminal(os.Stderr),
+		durationsFile: durationsFile,
+		attempts:      attemptCounter{},
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (r *progressReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.config = config
+	r.total = summary.NumberOfSpecsThatWillBeRun
+	r.done = 0
+	r.failed = 0
+	r.started = time.Now()
+	r.durations = SpecDurations{}
+	r.history = SpecDurations{}
+	if err := readJSON(r.durationsFile, &r.history); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "biloba: no estimate of the time left: %s\n", err)
+	}
+	r.unseen = SpecDurations{}
+	r.seen = map[string]bool{}
+	r.remaining = 0
+	for id, duration := range r.history {
+		r.unseen[id] = duration
+		r.remaining += duration
+	}
+
+	if r.tty {
+		r.stopRedraw = make(chan struct{})
+		go r.redraw(r.stopRedraw)
+	}
+}
+
+// redraw keeps redrawing the status line until stop is closed.
+func (r *progressReporter) redraw(stop chan struct{}) {
+	ticker := time.NewTicker(progressRedrawInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			if r.stopRedraw == stop {
+				r.printStatus()
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *progressReporter) SpecWillRun(spec *types.SpecSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts.start(spec)
+	if !spec.Skipped() && !spec.Pending() {
+		r.running = testName(spec)
+		r.printStatus()
+	}
+}
+
+func (r *progressReporter) SpecDidComplete(spec *types.SpecSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running = ""
+	// specs skipped because they aren't focused are reported too, and won't
+	// take their previous duration either
+	id := SpecID(spec)
+	r.seen[id] = true
+	if previous, ok := r.unseen[id]; ok {
+		delete(r.unseen, id)
+		r.remaining -= previous
+	}
+	if !ranSpec(spec) || (!spec.Passed() && r.attempts.current(spec) < r.config.FlakeAttempts) {
+		return
+	}
+
+	r.done++
+	if spec.HasFailureState() {
+		r.failed++
+	}
+	r.durations[id] = spec.RunTime
+
+	if r.tty {
+		r.printStatus()
+		return
+	}
+	fmt.Fprintf(r.out, "%s %s: %s (%s)\n", r.status(), strings.ToUpper(stateName(spec.State)), testName(spec), formatDuration(spec.RunTime))
+}
+
+func (r *progressReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopRedraw != nil {
+		close(r.stopRedraw)
+		r.stopRedraw = nil
+	}
+	if r.tty {
+		fmt.Fprintln(r.out)
+	}
+	// keep the durations of specs that didn't run this time, e.g. unfocused
+	// ones, but drop those of specs that are gone. A parallel node only sees
+	// its own specs.
+	if r.config.ParallelTotal <= 1 {
+		for id := range r.history {
+			if !r.seen[id] {
+				delete(r.history, id)
+			}
+		}
+	}
+	for id, duration := range r.durations {
+		r.history[id] = duration
+	}
+	if err := writeJSON(r.durationsFile, r.history); err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to write spec durations: %s\n", err)
+	}
+}
+
+// printStatus redraws the status line on a terminal.
+func (r *progressReporter) printStatus() {
+	if !r.tty {
+		return
+	}
+	status := r.status()
+	if r.running != "" {
+		status += " | " + r.running
+	}
+	fmt.Fprintf(r.out, "\r\033[K%s", status)
+}
+
+func (r *progressReporter) status() string {
+	return fmt.Sprintf("[%d/%d] %d failed | %s elapsed | ETA %s", r.done, r.total, r.failed, formatClock(time.Since(r.started)), r.estimate())
+}
+
+// estimate is the time left: the previous durations of the specs that haven't
+// run yet, or, for a suite without history, the average duration so far. When
+// more specs haven't been reported than are left to run, some of them will be
+// skipped, so only their average duration counts for each spec left.
+func (r *progressReporter) estimate() string {
+	left := r.total - r.done
+	switch {
+	case left <= 0:
+		return formatClock(0)
+	case len(r.unseen) > left:
+		return formatClock(r.remaining / time.Duration(len(r.unseen)) * time.Duration(left))
+	case len(r.unseen) > 0:
+		return formatClock(r.remaining)
+	case r.done > 0:
+		return formatClock(time.Since(r.started) / time.Duration(r.done) * time.Duration(r.total-r.done))
+	default:
+		return "unknown"
+	}
+}
+
+// ranSpec tells specs that ran apart from those skipped without running, e.g.
+// because they aren't focused. Specs that call Skip have a failure location.
+func ranSpec(spec *types.SpecSummary) bool {
+	if spec.Pending() {
+		return false
+	}
+	return !spec.Skipped() || spec.Failure.Location.FileName != ""
+}
+
+func formatClock(duration time.Duration) string {
+	duration = duration.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(duration.Minutes()), int(duration.Seconds())%60)
+}
+
+func (r *progressReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+func (r *progressReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+// force compatibility
+var _ ginkgo.Reporter = new(progressReporter)
@@ -0,0 +1,119 @@
+package biloba
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+// DefaultQuarantineFile is where the quarantine list is read from, relative to
+// the package under test, unless BILOBA_QUARANTINE_FILE is set.
+const DefaultQuarantineFile = ".biloba/quarantine.json"
+
+const quarantinedFailurePrefix = "quarantined failure: "
+
+// Quarantine lists known-flaky specs. Quarantined specs still run, but when
+// the biloba.Fail handler is registered their failures skip the spec instead
+// of failing the suite.
+type Quarantine struct {
+	Specs []QuarantineEntry `json:"specs"`
+}
+
+// QuarantineEntry matches specs by a regular expression on their full text,
+// on their code location ("file:line"), or both.
+type QuarantineEntry struct {
+	Text     string `json:"text,omitempty"`
+	Location string `json:"location,omitempty"`
+	Owner    string `json:"owner"`
+	Ticket   string `json:"ticket"`
+
+	textRegexp     *regexp.Regexp
+	locationRegexp *regexp.Regexp
+}
+
+func (e *QuarantineEntry) compile() error {
+	var err error
+	if e.Text != "" {
+		if e.textRegexp, err = regexp.Compile(e.Text); err != nil {
+			return err
+		}
+	}
+	if e.Location != "" {
+		if e.locationRegexp, err = regexp.Compile(e.Location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *QuarantineEntry) matches(fullText string, location string) bool {
+	if e.textRegexp != nil && e.textRegexp.MatchString(fullText) {
+		return true
+	}
+	return e.locationRegexp != nil && e.locationRegexp.MatchString(location)
+}
+
+func (e *QuarantineEntry) String() string {
+	return fmt.Sprintf("owner: %s, ticket: %s", e.Owner, e.Ticket)
+}
+
+var (
+	quarantineOnce sync.Once
+	quarantineList Quarantine
+)
+
+// loadedQuarantine reads the quarantine list once per process. A missing file
+// means that nothing is quarantined.
+func loadedQuarantine() *Quarantine {
+	quarantineOnce.Do(func() {
+		filename := os.Getenv("BILOBA_QUARANTINE_FILE")
+		if filename == "" {
+			filename = DefaultQuarantineFile
+		}
+		var quarantine Quarantine
+		if err := readJSON(filename, &quarantine); err != nil {
+			if !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "biloba: failed to read quarantine file: %s\n", err)
+			}
+			return
+		}
+		for i := range quarantine.Specs {
+			if err := quarantine.Specs[i].compile(); err != nil {
+				fmt.Fprintf(os.Stderr, "biloba: invalid quarantine entry: %s\n", err)
+				return
+			}
+		}
+		quarantineList = quarantine
+	})
+	return &quarantineList
+}
+
+func (q *Quarantine) find(fullText string, location string) *QuarantineEntry {
+	for i := range q.Specs {
+		if q.Specs[i].matches(fullText, location) {
+			return &q.Specs[i]
+		}
+	}
+	return nil
+}
+
+func quarantineEntry(spec *types.SpecSummary) *QuarantineEntry {
+	return loadedQuarantine().find(strings.Join(spec.ComponentTexts[1:], " "), specLocation(spec).String())
+}
+
+// quarantinedFailure returns the quarantine entry of a spec whose failure was
+// turned into a skip by the biloba.Fail handler, or of a quarantined spec that
+// failed anyway (e.g. by panicking).
+func quarantinedFailure(spec *types.SpecSummary) *QuarantineEntry {
+	if spec.Skipped() && !strings.HasPrefix(spec.Failure.Message, quarantinedFailurePrefix) {
+		return nil
+	}
+	if !spec.Skipped() && !spec.HasFailureState() {
+		return nil
+	}
+	return quarantineEntry(spec)
+}
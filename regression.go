@@ -0,0 +1,160 @@
+package biloba
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// DurationRegression is a spec that got slower than in the baseline.
+type DurationRegression struct {
+	ID       string
+	Name     string
+	Location string
+	Baseline time.Duration
+	RunTime  time.Duration
+}
+
+// Ratio is how many times longer the spec took than in the baseline.
+func (r DurationRegression) Ratio() float64 {
+	if r.Baseline == 0 {
+		return 0
+	}
+	return float64(r.RunTime) / float64(r.Baseline)
+}
+
+// durationRegressions compares the specs that passed in both reports by ID,
+// returning those that slowed down, the largest slowdowns first.
+func durationRegressions(baseline, current Report, ratio float64, threshold time.Duration) []DurationRegression {
+	passed := stateName(types.SpecStatePassed)
+	baselineRunTimes := map[string]time.Duration{}
+	for _, spec := range baseline.Specs {
+		if spec.State == passed {
+			baselineRunTimes[spec.ID] = spec.RunTime
+		}
+	}
+
+	var regressions []DurationRegression
+	for _, spec := range current.Specs {
+		baselineRunTime, ok := baselineRunTimes[spec.ID]
+		if !ok || spec.State != passed {
+			continue
+		}
+		if !slowedDown(baselineRunTime, spec.RunTime, ratio, threshold) {
+			continue
+		}
+		regressions = append(regressions, DurationRegression{
+			ID:       spec.ID,
+			Name:     spec.Name,
+			Location: spec.Location,
+			Baseline: baselineRunTime,
+			RunTime:  spec.RunTime,
+		})
+	}
+	sort.SliceStable(regressions, func(i, j int) bool {
+		return regressions[i].RunTime-regressions[i].Baseline > regressions[j].RunTime-regressions[j].Baseline
+	})
+	return regressions
+}
+
+// slowedDown reports whether runTime is more than ratio times and more than
+// threshold longer than baseline. A ratio of 0 only applies the threshold.
+func slowedDown(baseline, runTime time.Duration, ratio float64, threshold time.Duration) bool {
+	if runTime-baseline <= threshold {
+		return false
+	}
+	return ratio <= 0 || float64(runTime) > ratio*float64(baseline)
+}
+
+type durationRegressionReporter struct {
+	*reportCollector
+	baselineFile string
+	ratio        float64
+	threshold    time.Duration
+	t            ginkgo.GinkgoTestingT
+	out          io.Writer
+
+	testFunc    string
+	baseline    Report
+	hasBaseline bool
+	current     Report
+}
+
+// NewDurationRegressionReporter compares the duration of every spec with the
+// report written by NewJSONReporter in baselineFile, and once the suite ends
+// prints the specs that took more than ratio times as long and more than
+// threshold longer than in the baseline. Specs that didn't pass in both runs
+// are ignored, as is a missing baselineFile, so baselineFile can be the
+// report that the JSON reporter of the same suite writes after this one.
+func NewDurationRegressionReporter(baselineFile string, ratio float64, threshold time.Duration) *durationRegressionReporter {
+	r := &durationRegressionReporter{
+		baselineFile: baselineFile,
+		ratio:        ratio,
+		threshold:    threshold,
+		out:          os.Stdout,
+	}
+	r.reportCollector = newReportCollector(func(report Report) { r.current = report })
+	return r
+}
+
+// FailSuite makes the reporter fail t, usually the *testing.T passed to
+// RunSpecs, when a spec regressed.
+func (r *durationRegressionReporter) FailSuite(t ginkgo.GinkgoTestingT) *durationRegressionReporter {
+	r.t = t
+	return r
+}
+
+func (r *durationRegressionReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.reportCollector.SpecSuiteWillBegin(config, summary)
+	r.testFunc = suiteTestFunc()
+
+	baseline, err := ReadReport(r.baselineFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "biloba: failed to read duration baseline: %s\n", err)
+		return
+	}
+	r.baseline = baseline
+	r.hasBaseline = true
+}
+
+func (r *durationRegressionReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.finish(summary)
+	if !r.hasBaseline {
+		return
+	}
+
+	regressions := durationRegressions(r.baseline, r.current, r.ratio, r.threshold)
+	if len(regressions) == 0 {
+		return
+	}
+	continueTestFunc(r.out, r.testFunc)
+	fmt.Fprintf(r.out, "\nDuration regressions against %s (%s):\n", r.baselineFile, r.criteria())
+	table := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	for _, regression := range regressions {
+		fmt.Fprintf(table, "    %.3fs\twas %.3fs\t%.2fx\t%s\t%s\n", regression.RunTime.Seconds(), regression.Baseline.Seconds(), regression.Ratio(), regression.Name, regression.Location)
+	}
+	table.Flush()
+	if r.t != nil {
+		r.t.Fail()
+	}
+}
+
+func (r *durationRegressionReporter) criteria() string {
+	if r.ratio > 0 {
+		return fmt.Sprintf("over %.2fx and %s slower", r.ratio, r.threshold)
+	}
+	return fmt.Sprintf("over %s slower", r.threshold)
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(durationRegressionReporter)
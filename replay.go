@@ -0,0 +1,159 @@
+package biloba
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/matt-royal/biloba/events"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/types"
+)
+
+var (
+	// replayLock serializes calls to Replay, which share replaying
+	replayLock sync.Mutex
+	replaying  = &replayState{}
+)
+
+// replayState holds what Replay knows about the recorded run, which reporters
+// would otherwise take from the replaying process: the package and test
+// function that ran the suite and the spec IDs, as the IDs depend on the
+// module the specs are in.
+type replayState struct {
+	mu       sync.Mutex
+	active   bool
+	pkg      string
+	testFunc string
+	ids      map[string]string
+}
+
+func (s *replayState) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = true
+	s.pkg = ""
+	s.testFunc = ""
+	s.ids = map[string]string{}
+}
+
+func (s *replayState) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = false
+	s.ids = nil
+}
+
+func (s *replayState) suite(event events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pkg = event.Package
+	s.testFunc = event.TestFunc
+}
+
+func (s *replayState) spec(event events.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.SpecID != "" {
+		s.ids[specKey(event.Spec)] = event.SpecID
+	}
+}
+
+// specID returns the recorded ID of a replayed spec.
+func (s *replayState) specID(spec *types.SpecSummary) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.ids[specKey(spec)]
+	return id, ok
+}
+
+// suitePackage is the import path of the package under test, as recorded in
+// the event log while replaying. It is empty when replaying a log that
+// doesn't record it.
+func suitePackage() string {
+	replaying.mu.Lock()
+	defer replaying.mu.Unlock()
+	if replaying.active {
+		return replaying.pkg
+	}
+	return currentPackage()
+}
+
+// suiteTestFunc is the go test function running the suite, as recorded in the
+// event log while replaying.
+func suiteTestFunc() string {
+	replaying.mu.Lock()
+	defer replaying.mu.Unlock()
+	if replaying.active {
+		return replaying.testFunc
+	}
+	return currentTestFunc()
+}
+
+// Replay feeds a run recorded by the event log reporter through reporters,
+// calling them as ginkgo did during the run, so that reports in any format
+// can be generated without running the specs again.
+//
+// Reporters see the package, test function and spec IDs of the recorded run
+// rather than those of the replaying process.
+func Replay(r io.Reader, reporters ...ginkgo.Reporter) error {
+	replayLock.Lock()
+	defer replayLock.Unlock()
+	replaying.begin()
+	defer replaying.end()
+
+	reader := events.NewReader(r)
+	ended := false
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch event.Type {
+		case events.SuiteWillBegin:
+			// the events of a parallel node replay as a whole suite
+			config := *event.Config
+			config.ParallelNode = 1
+			config.ParallelTotal = 1
+			replaying.suite(event)
+			for _, reporter := range reporters {
+				reporter.SpecSuiteWillBegin(config, event.Suite)
+			}
+		case events.BeforeSuiteDidRun:
+			for _, reporter := range reporters {
+				reporter.BeforeSuiteDidRun(event.Setup)
+			}
+		case events.SpecWillRun:
+			replaying.spec(event)
+			for _, reporter := range reporters {
+				reporter.SpecWillRun(event.Spec)
+			}
+		case events.SpecDidComplete:
+			replaying.spec(event)
+			// like ginkgo, report to the first reporter last
+			for i := len(reporters) - 1; i >= 0; i-- {
+				reporters[i].SpecDidComplete(event.Spec)
+			}
+		case events.AfterSuiteDidRun:
+			for _, reporter := range reporters {
+				reporter.AfterSuiteDidRun(event.Setup)
+			}
+		case events.SuiteDidEnd:
+			for _, reporter := range reporters {
+				reporter.SpecSuiteDidEnd(event.Suite)
+			}
+			ended = true
+		default:
+			return fmt.Errorf("unknown event type %q", event.Type)
+		}
+	}
+
+	if !ended {
+		return fmt.Errorf("the event log ends before the suite did")
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package biloba
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// Report is the structured result of a suite run, as written by the JSON reporter.
+type Report struct {
+	Suite     string        `json:"suite"`
+	Succeeded bool          `json:"succeeded"`
+	RunTime   time.Duration `json:"run_time"`
+	Specs     []SpecReport  `json:"specs"`
+}
+
+// SpecReport is the final outcome of a single spec. Retried specs appear once,
+// with the state of their last attempt.
+type SpecReport struct {
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	ComponentTexts  []string      `json:"component_texts"`
+	Location        string        `json:"location"`
+	State           string        `json:"state"`
+	RunTime         time.Duration `json:"run_time"`
+	Attempts        int           `json:"attempts"`
+	Flaky           bool          `json:"flaky,omitempty"`
+	Slow            bool          `json:"slow,omitempty"`
+	Failure         string        `json:"failure,omitempty"`
+	FailureLocation string        `json:"failure_location,omitempty"`
+	Output          string        `json:"output,omitempty"`
+
+	// Quarantine is set when a quarantined spec failed
+	Quarantine *QuarantineEntry `json:"quarantine,omitempty"`
+
+	// ExpectedFailure is set when a spec that is expected to fail did, in
+	// which case its state is "passed"
+	ExpectedFailure bool `json:"expected_failure,omitempty"`
+	// UnexpectedPass is set when a spec that is expected to fail passed, in
+	// which case its state is "failed"
+	UnexpectedPass bool `json:"unexpected_pass,omitempty"`
+
+	// Resources is set when resource usage is collected
+	Resources *ResourceUsage `json:"resources,omitempty"`
+	// Profiles is set when a profiled spec was slow
+	Profiles *SpecProfiles `json:"profiles,omitempty"`
+}
+
+// reportCollector builds a Report from ginkgo's events. Reporters that write
+// structured reports embed it and implement SpecSuiteDidEnd, calling finish.
+type reportCollector struct {
+	mu       sync.Mutex
+	report   Report
+	attempts attemptCounter
+	index    map[string]int
+
+	running      *types.SpecSummary
+	runningSince time.Time
+	unregister   func()
+
+	// write is called with the report when the suite ends or is aborted,
+	// whichever comes first, as ginkgo still ends an interrupted suite
+	write   func(Report)
+	written bool
+}
+
+func newReportCollector(write func(Report)) *reportCollector {
+	return &reportCollector{
+		attempts: attemptCounter{},
+		index:    map[string]int{},
+		write:    write,
+	}
+}
+
+func (c *reportCollector) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	c.report = Report{Suite: summary.SuiteDescription, Specs: []SpecReport{}}
+	c.written = false
+	c.unregister = aborts.register(c.abort)
+}
+
+func (c *reportCollector) SpecWillRun(spec *types.SpecSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempts.start(spec)
+	c.running = spec
+	resources.start(spec)
+	profiles.start(spec)
+	c.runningSince = time.Now()
+}
+
+func (c *reportCollector) SpecDidComplete(spec *types.SpecSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.running = nil
+	c.record(spec)
+}
+
+func (c *reportCollector) record(spec *types.SpecSummary) {
+	attempt := c.attempts.current(spec)
+	specReport := SpecReport{
+		ID:             SpecID(spec),
+		Name:           testName(spec),
+		ComponentTexts: spec.ComponentTexts[1:],
+		Location:       specLocation(spec).String(),
+		State:          stateName(spec.State),
+		RunTime:        spec.RunTime,
+		Attempts:       attempt,
+		Flaky:          spec.Passed() && attempt > 1,
+		Slow:           isSlow(spec),
+		Output:         spec.CapturedOutput,
+		Resources:      resources.usage(spec),
+		Profiles:       profiles.profiles(spec),
+	}
+	if spec.HasFailureState() {
+		specReport.Failure = spec.Failure.Message
+		specReport.FailureLocation = spec.Failure.Location.String()
+	}
+	if entry := quarantinedFailure(spec); entry != nil {
+		specReport.Quarantine = entry
+		specReport.Failure = strings.TrimPrefix(spec.Failure.Message, quarantinedFailurePrefix)
+		specReport.FailureLocation = spec.Failure.Location.String()
+	}
+	if expectedFailure(spec) {
+		specReport.State = stateName(types.SpecStatePassed)
+		specReport.ExpectedFailure = true
+		specReport.Failure = strings.TrimPrefix(spec.Failure.Message, expectedFailurePrefix)
+		specReport.FailureLocation = spec.Failure.Location.String()
+	} else if unexpectedPass(spec) {
+		specReport.State = stateName(types.SpecStateFailed)
+		specReport.UnexpectedPass = true
+		specReport.Failure = unexpectedPassMessage
+		specReport.FailureLocation = specLocation(spec).String()
+	}
+
+	key := specKey(spec)
+	if i, ok := c.index[key]; ok {
+		c.report.Specs[i] = specReport
+		return
+	}
+	c.index[key] = len(c.report.Specs)
+	c.report.Specs = append(c.report.Specs, specReport)
+}
+
+// finish completes the report once ginkgo ends the suite.
+func (c *reportCollector) finish(summary *types.SuiteSummary) {
+	if c.unregister != nil {
+		c.unregister()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.written {
+		return
+	}
+
+	// ginkgo only ends the suite while a spec is running when it was interrupted
+	c.recordRunningSpec("interrupted")
+	c.report.Succeeded = summary.SuiteSucceeded
+	c.report.RunTime = summary.RunTime
+	c.written = true
+	c.write(c.report)
+}
+
+// abort writes the report early, including the in-flight spec as a failure,
+// since the process is about to die without ginkgo ending the suite.
+func (c *reportCollector) abort(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.written {
+		return
+	}
+
+	c.recordRunningSpec(reason)
+	c.report.Succeeded = false
+	c.written = true
+	c.write(c.report)
+}
+
+func (c *reportCollector) recordRunningSpec(reason string) {
+	if c.running == nil {
+		return
+	}
+	spec := *c.running
+	spec.State = types.SpecStateFailed
+	spec.RunTime = time.Since(c.runningSince)
+	spec.Failure = types.SpecFailure{
+		Message:  "spec " + reason,
+		Location: specLocation(&spec),
+	}
+	c.running = nil
+	c.record(&spec)
+}
+
+func (c *reportCollector) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+func (c *reportCollector) AfterSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+// ReadReport loads a Report written by the JSON reporter.
+func ReadReport(filename string) (Report, error) {
+	var report Report
+	err := readJSON(filename, &report)
+	return report, err
+}
+
+func readJSON(filename string, v interface{}) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// failedState reports whether a state name from a report is a failure.
+func failedState(state string) bool {
+	switch state {
+	case stateName(types.SpecStatePassed), stateName(types.SpecStateSkipped), stateName(types.SpecStatePending):
+		return false
+	default:
+		return true
+	}
+}
+
+func stateName(state types.SpecState) string {
+	switch state {
+	case types.SpecStatePassed:
+		return "passed"
+	case types.SpecStateFailed:
+		return "failed"
+	case types.SpecStatePanicked:
+		return "panicked"
+	case types.SpecStateTimedOut:
+		return "timed out"
+	case types.SpecStateSkipped:
+		return "skipped"
+	case types.SpecStatePending:
+		return "pending"
+	default:
+		return "invalid"
+	}
+}
@@ -1,12 +1,16 @@
 package biloba
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/onsi/ginkgo/reporters"
 	"github.com/onsi/ginkgo/reporters/stenographer"
 	"github.com/onsi/ginkgo/reporters/stenographer/support/go-colorable"
+	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
@@ -14,6 +18,27 @@ import (
 )
 
 type gotestCompatibleReporter struct {
+	mu       sync.Mutex
+	config   config.GinkgoConfigType
+	suite    string
+	pkg      string
+	testFunc string
+	attempts attemptCounter
+	flaky    []string
+	slow     []slowSpec
+
+	running      *types.SpecSummary
+	runningSince time.Time
+	watchdog     *watchdog
+	aborted      bool
+	unregister   func()
+
+	// out is where output is written. While a spec runs in parallel mode it
+	// points at block, which is handed to sink as a whole once the spec ends.
+	out    io.Writer
+	stdout io.Writer
+	sink   blockSink
+	block  bytes.Buffer
 }
 
 func GoLandReporter() []ginkgo.Reporter {
@@ -24,7 +49,11 @@ func GoLandReporter() []ginkgo.Reporter {
 }
 
 func NewGoTestCompatibleReporter() *gotestCompatibleReporter {
-	return new(gotestCompatibleReporter)
+	return &gotestCompatibleReporter{
+		attempts: attemptCounter{},
+		out:      os.Stdout,
+		stdout:   os.Stdout,
+	}
 }
 
 // deprecated: Use ginkgo.RunSpecsWithDefaultAndCustomReporters with GoLandReporter() instead
@@ -35,15 +64,45 @@ func DefaultReporters() []ginkgo.Reporter {
 	return append(GoLandReporter(), defaultReporter)
 }
 
+func (r *gotestCompatibleReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.config = config
+	r.suite = summary.SuiteDescription
+	r.pkg = suitePackage()
+	r.testFunc = suiteTestFunc()
+	r.sink = newBlockSink(config, r.stdout)
+	r.unregister = aborts.register(r.abortRunningSpec)
+}
+
 func (r *gotestCompatibleReporter) SpecWillRun(specSummary *types.SpecSummary) {
-	fmt.Printf("\n=== RUN   %s\n", testName(specSummary))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.aborted {
+		return
+	}
+
+	r.attempts.start(specSummary)
+	r.running = specSummary
+	r.runningSince = time.Now()
+	r.watchdog = startWatchdog(func(elapsed time.Duration) { r.reportHang(specSummary, elapsed) })
+	leaks.snapshot()
+	resources.start(specSummary)
+	profiles.start(specSummary)
+	r.beginBlock()
+	fmt.Fprintf(r.out, "\n=== RUN   %s\n", r.attemptName(specSummary))
+	fmt.Fprintf(r.out, "# spec id: %s\n", SpecID(specSummary))
 }
 
 func (r *gotestCompatibleReporter) SpecDidComplete(spec *types.SpecSummary) {
-	name := testName(spec)
-	seconds := spec.RunTime.Milliseconds() / 1000
-	milliseconds := spec.RunTime.Milliseconds() % 1000
-	durationStr := fmt.Sprintf("%d.%ds", seconds, milliseconds)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.aborted {
+		return
+	}
+	r.running = nil
+	r.watchdog.Stop()
+
+	name := r.attemptName(spec)
+	durationStr := formatDuration(spec.RunTime)
 	var state string
 	switch {
 	case spec.Passed():
@@ -55,8 +114,144 @@ func (r *gotestCompatibleReporter) SpecDidComplete(spec *types.SpecSummary) {
 	default:
 		panic("Unknown state")
 	}
-	fmt.Printf("\n--- %s: %s (%s)\n", state, name, durationStr)
+	if expectedFailure(spec) {
+		state = "PASS"
+	}
+	if attempt := r.attempts.current(spec); spec.Passed() && attempt > 1 {
+		fmt.Fprintf(r.out, "\nFLAKY: %s passed on attempt %d of %d\n", testName(spec), attempt, r.config.FlakeAttempts)
+		r.flaky = append(r.flaky, fmt.Sprintf("%s (attempt %d)", testName(spec), attempt))
+	}
+	if leaked := leaks.leaked(); len(leaked) > 0 {
+		fmt.Fprintf(r.out, "\nLEAKED GOROUTINES: %s\n", leakMessage(leaked))
+	}
+	if usage := resources.usage(spec); usage != nil {
+		fmt.Fprintf(r.out, "\nRESOURCES: %s\n", usage)
+	}
+	if specProfiles := profiles.profiles(spec); specProfiles != nil {
+		fmt.Fprintf(r.out, "\nPROFILES: cpu %s, heap %s\n", specProfiles.CPU, specProfiles.Heap)
+	}
+	if isSlow(spec) {
+		fmt.Fprintf(r.out, "\nSLOW: %s took longer than %s\n", testName(spec), formatDuration(slowSpecThreshold()))
+		r.slow = append(r.slow, slowSpec{name: name, runTime: spec.RunTime, location: specLocation(spec)})
+	}
+	if spec.IsMeasurement && spec.Passed() {
+		fmt.Fprintln(r.out)
+		for _, line := range benchmarkLines(r.suite, spec) {
+			fmt.Fprintln(r.out, line)
+		}
+	}
+	if entry := quarantinedFailure(spec); entry != nil {
+		message := strings.TrimPrefix(spec.Failure.Message, quarantinedFailurePrefix)
+		fmt.Fprintf(r.out, "\nQUARANTINED (%s): %s\n%s\n", entry, message, spec.Failure.Location)
+	} else if expectedFailure(spec) {
+		message := strings.TrimPrefix(spec.Failure.Message, expectedFailurePrefix)
+		fmt.Fprintf(r.out, "\nEXPECTED FAILURE: %s\n%s\n", message, spec.Failure.Location)
+	} else if r.sink != nil && spec.HasFailureState() {
+		// the default reporter's failure details are printed by another node
+		fmt.Fprintf(r.out, "\n%s\n%s\n", spec.Failure.Message, spec.Failure.Location)
+	}
+	if state == "FAIL" && r.pkg != "" {
+		fmt.Fprintf(r.out, "\nTo reproduce: %s\n", reproduceCommand(r.pkg, r.testFunc, r.suite, r.config, spec))
+	}
+	fmt.Fprintf(r.out, "\n--- %s: %s (%s)\n", state, name, durationStr)
+	r.endBlock()
+}
+
+func (r *gotestCompatibleReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	if r.unregister != nil {
+		r.unregister()
+	}
+	// ginkgo only ends the suite while a spec is running when it was interrupted
+	r.abortRunningSpec("interrupted")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sink != nil {
+		r.sink.close()
+	}
+	if len(r.slow) > 0 || len(r.flaky) > 0 {
+		continueTestFunc(r.out, r.testFunc)
+	}
+	printSlowestSpecs(r.out, r.slow)
+	if len(r.flaky) == 0 {
+		return
+	}
+	fmt.Fprintf(r.out, "\nFlaky specs (passed on retry):\n")
+	for _, name := range r.flaky {
+		fmt.Fprintf(r.out, "    %s\n", name)
+	}
+}
+
+// abortRunningSpec closes the block of the in-flight spec, if any, so that
+// test2json and IDEs don't show it as running forever.
+func (r *gotestCompatibleReporter) abortRunningSpec(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil || r.aborted {
+		return
+	}
+	r.aborted = true
+	r.watchdog.Stop()
 
+	printGoroutineDump(r.out, reason)
+	fmt.Fprintf(r.out, "\n--- FAIL: %s (%s)\n", r.attemptName(r.running), formatDuration(time.Since(r.runningSince)))
+	r.endBlock()
+	r.running = nil
+}
+
+// reportHang is called by the watchdog of a spec that runs for too long.
+func (r *gotestCompatibleReporter) reportHang(spec *types.SpecSummary, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running != spec || r.aborted {
+		return
+	}
+
+	report := fmt.Sprintf("\nHANG: %s has been running for %s. Goroutine dump:\n\n%s\n", testName(spec), formatClock(elapsed), goroutineDump())
+	fmt.Fprint(r.out, report)
+	if r.sink != nil {
+		// the spec's block is only printed once it ends
+		fmt.Fprint(os.Stderr, report)
+	}
+}
+
+// continueTestFunc prints the line go test prints when a test resumes, so that
+// test2json and IDEs attribute the summaries printed at the end of the suite
+// to the go test function running it rather than to the last spec.
+func continueTestFunc(out io.Writer, testFunc string) {
+	if testFunc != "" {
+		fmt.Fprintf(out, "\n=== CONT  %s\n", testFunc)
+	}
+}
+
+func (r *gotestCompatibleReporter) beginBlock() {
+	if r.sink != nil {
+		r.block.Reset()
+		r.out = &r.block
+	}
+}
+
+func (r *gotestCompatibleReporter) endBlock() {
+	if r.sink != nil {
+		r.out = r.stdout
+		r.sink.send(r.block.String())
+	}
+}
+
+// attemptName distinguishes retries of a spec under -ginkgo.flakeAttempts so
+// that each attempt gets its own RUN/PASS block, as go test does for -count.
+func (r *gotestCompatibleReporter) attemptName(spec *types.SpecSummary) string {
+	name := testName(spec)
+	if attempt := r.attempts.current(spec); attempt > 1 {
+		name = fmt.Sprintf("%s#attempt%d", name, attempt)
+	}
+	return name
+}
+
+func formatDuration(duration time.Duration) string {
+	seconds := duration.Milliseconds() / 1000
+	milliseconds := duration.Milliseconds() % 1000
+	return fmt.Sprintf("%d.%03ds", seconds, milliseconds)
 }
 
 func testName(spec *types.SpecSummary) string {
@@ -67,16 +262,33 @@ func testName(spec *types.SpecSummary) string {
 	)
 }
 
-// No-Op methods for compatibility with ginkgo.Reporter
+// attemptCounter tracks how many times ginkgo has started each spec. Ginkgo
+// reports every flake attempt as a separate SpecWillRun/SpecDidComplete pair.
+type attemptCounter map[string]int
 
-func (r *gotestCompatibleReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {}
+func (c attemptCounter) start(spec *types.SpecSummary) int {
+	key := specKey(spec)
+	c[key]++
+	return c[key]
+}
 
-func (r *gotestCompatibleReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {}
+func (c attemptCounter) current(spec *types.SpecSummary) int {
+	return c[specKey(spec)]
+}
 
-func (r *gotestCompatibleReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {}
+func specKey(spec *types.SpecSummary) string {
+	return strings.Join(spec.ComponentTexts, " ") + "@" + specLocation(spec).String()
+}
 
-func (r *gotestCompatibleReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+func specLocation(spec *types.SpecSummary) types.CodeLocation {
+	return spec.ComponentCodeLocations[len(spec.ComponentCodeLocations)-1]
 }
 
+// No-Op methods for compatibility with ginkgo.Reporter
+
+func (r *gotestCompatibleReporter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {}
+
+func (r *gotestCompatibleReporter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {}
+
 // force compatibility
 var _ ginkgo.Reporter = new(gotestCompatibleReporter)
@@ -4,13 +4,19 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"github.com/matt-royal/biloba"
+	"github.com/matt-royal/biloba/events"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"strings"
 	"time"
 )
 
@@ -21,10 +27,21 @@ type testJsonEntry struct {
 }
 
 var _ = Describe("GoTestCompatibleReporter", func() {
-	var projectRoot string
+	var (
+		projectRoot string
+		tempDir     string
+	)
 
 	BeforeEach(func() {
 		projectRoot = os.Getenv("PWD")
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "biloba")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
 	})
 
 	When("the tests pass", func() {
@@ -48,6 +65,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[1]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 A test 1 passes", Output: "\n",},
 				{Action: "output", Test: "level 1 A test 1 passes", Output: "=== RUN   level 1 A test 1 passes\n",},
+				{Action: "output", Test: "level 1 A test 1 passes", Output: "# spec id: 5b72dfefbb2eb39f\n",},
 				{Action: "output", Test: "level 1 A test 1 passes", Output: "•\n",},
 				{Action: "output", Test: "level 1 A test 1 passes", Output: "--- PASS: level 1 A test 1 passes (TIME)\n",},
 				{Action: "output", Test: "level 1 A test 1 passes", Output: "\n",},
@@ -57,6 +75,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[2]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 A test 2 passes", Output: "\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "=== RUN   level 1 A test 2 passes\n",},
+				{Action: "output", Test: "level 1 A test 2 passes", Output: "# spec id: 54a9b48d2d8f6361\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "•\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "--- PASS: level 1 A test 2 passes (TIME)\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "\n",},
@@ -66,6 +85,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[3]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 B test 1 passes", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 1 passes", Output: "=== RUN   level 1 B test 1 passes\n",},
+				{Action: "output", Test: "level 1 B test 1 passes", Output: "# spec id: 806db90c35d94d5c\n",},
 				{Action: "output", Test: "level 1 B test 1 passes", Output: "•\n",},
 				{Action: "output", Test: "level 1 B test 1 passes", Output: "--- PASS: level 1 B test 1 passes (TIME)\n",},
 				{Action: "output", Test: "level 1 B test 1 passes", Output: "\n",},
@@ -75,6 +95,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[4]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 B test 2 passes", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "=== RUN   level 1 B test 2 passes\n",},
+				{Action: "output", Test: "level 1 B test 2 passes", Output: "# spec id: 2020e222172c7072\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "•\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "--- PASS: level 1 B test 2 passes (TIME)\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "\n",},
@@ -114,6 +135,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[1]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 A test 1 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "=== RUN   level 1 A test 1 fails\n",},
+				{Action: "output", Test: "level 1 A test 1 fails", Output: "# spec id: 5ffe3b4dc51a03f9\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "• Failure [TIME]\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "level 1\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: fmt.Sprintf("%s/test_assets/failing/failing_test.go:8\n", projectRoot),},
@@ -130,6 +152,8 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "output", Test: "level 1 A test 1 fails", Output: fmt.Sprintf("    %s/test_assets/failing/failing_test.go:11\n", projectRoot)},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "\n",},
+				{Action: "output", Test: "level 1 A test 1 fails", Output: "To reproduce: go test github.com/matt-royal/biloba/test_assets/failing -run '^TestFailing$' -ginkgo.focus='^Failing Suite \\[Top Level\\] level 1 A test 1 fails$' -ginkgo.seed=1234\n"},
+				{Action: "output", Test: "level 1 A test 1 fails", Output: "\n"},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "--- FAIL: level 1 A test 1 fails (TIME)\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "\n",},
 				{Action: "fail", Test: "level 1 A test 1 fails", Output: "\n",},
@@ -138,6 +162,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[2]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 A test 2 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "=== RUN   level 1 A test 2 fails\n",},
+				{Action: "output", Test: "level 1 A test 2 fails", Output: "# spec id: 3c100516777bb874\n",},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "• Failure [TIME]\n",},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "level 1\n",},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: fmt.Sprintf("%s/test_assets/failing/failing_test.go:8\n", projectRoot)},
@@ -154,6 +179,8 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "output", Test: "level 1 A test 2 fails", Output: fmt.Sprintf("    %s/test_assets/failing/failing_test.go:15\n", projectRoot)},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "\n",},
+				{Action: "output", Test: "level 1 A test 2 fails", Output: "To reproduce: go test github.com/matt-royal/biloba/test_assets/failing -run '^TestFailing$' -ginkgo.focus='^Failing Suite \\[Top Level\\] level 1 A test 2 fails$' -ginkgo.seed=1234\n"},
+				{Action: "output", Test: "level 1 A test 2 fails", Output: "\n"},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "--- FAIL: level 1 A test 2 fails (TIME)\n",},
 				{Action: "output", Test: "level 1 A test 2 fails", Output: "\n",},
 				{Action: "fail", Test: "level 1 A test 2 fails", Output: "\n",},
@@ -162,6 +189,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[3]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 B test 1 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "=== RUN   level 1 B test 1 fails\n",},
+				{Action: "output", Test: "level 1 B test 1 fails", Output: "# spec id: f588bf492b49fc8b\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "• Failure [TIME]\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "level 1\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: fmt.Sprintf("%s/test_assets/failing/failing_test.go:8\n", projectRoot)},
@@ -178,6 +206,8 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "output", Test: "level 1 B test 1 fails", Output: fmt.Sprintf("    %s/test_assets/failing/failing_test.go:21\n", projectRoot)},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n",},
+				{Action: "output", Test: "level 1 B test 1 fails", Output: "To reproduce: go test github.com/matt-royal/biloba/test_assets/failing -run '^TestFailing$' -ginkgo.focus='^Failing Suite \\[Top Level\\] level 1 B test 1 fails$' -ginkgo.seed=1234\n"},
+				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n"},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "--- FAIL: level 1 B test 1 fails (TIME)\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n",},
 				{Action: "fail", Test: "level 1 B test 1 fails", Output: "\n",},
@@ -186,6 +216,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[4]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 B test 2 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "=== RUN   level 1 B test 2 fails\n",},
+				{Action: "output", Test: "level 1 B test 2 fails", Output: "# spec id: 001f7a143c132ac0\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "• Failure [TIME]\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "level 1\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: fmt.Sprintf("%s/test_assets/failing/failing_test.go:8\n", projectRoot)},
@@ -202,6 +233,8 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "output", Test: "level 1 B test 2 fails", Output: fmt.Sprintf("    %s/test_assets/failing/failing_test.go:25\n", projectRoot)},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "\n",},
+				{Action: "output", Test: "level 1 B test 2 fails", Output: "To reproduce: go test github.com/matt-royal/biloba/test_assets/failing -run '^TestFailing$' -ginkgo.focus='^Failing Suite \\[Top Level\\] level 1 B test 2 fails$' -ginkgo.seed=1234\n"},
+				{Action: "output", Test: "level 1 B test 2 fails", Output: "\n"},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "--- FAIL: level 1 B test 2 fails (TIME)\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 2 fails", Output: "\n",},
@@ -233,6 +266,23 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "fail", Test: "TestFailing", Output: "FAIL\n"},
 			}))
 		})
+
+		It("prints a reproduce command that also scans the file path when the run did", func() {
+			lines := testOutputLines("./test_assets/failing", "-ginkgo.regexScansFilePath")
+
+			var command string
+			for _, line := range lines {
+				if line.Action == "output" && line.Test == "level 1 A test 1 fails" && strings.HasPrefix(line.Output, "To reproduce: ") {
+					command = strings.TrimSuffix(strings.TrimPrefix(line.Output, "To reproduce: "), "\n")
+				}
+			}
+			Expect(command).To(Equal(fmt.Sprintf("go test github.com/matt-royal/biloba/test_assets/failing -run '^TestFailing$' -ginkgo.focus='^Failing Suite \\[Top Level\\] level 1 A test 1 fails %s$' -ginkgo.seed=1234 -ginkgo.regexScansFilePath", regexp.QuoteMeta(projectRoot+"/test_assets/failing/failing_test.go"))))
+
+			cmd := exec.Command("bash", "-c", command+" -ginkgo.noColor")
+			cmd.Env = append(os.Environ(), "BILOBA_INTEGRATION_TEST=true")
+			output, _ := cmd.CombinedOutput()
+			Expect(string(output)).To(ContainSubstring("Ran 1 of 4 Specs"))
+		})
 	})
 
 	When("the some tests pass and some fail", func() {
@@ -256,6 +306,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[1]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 A test 1 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "=== RUN   level 1 A test 1 fails\n",},
+				{Action: "output", Test: "level 1 A test 1 fails", Output: "# spec id: 59cf257cda8dbd1d\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "• Failure [TIME]\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "level 1\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: fmt.Sprintf("%s/test_assets/mixed/mixed_test.go:8\n", projectRoot)},
@@ -272,6 +323,8 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "output", Test: "level 1 A test 1 fails", Output: fmt.Sprintf("    %s/test_assets/mixed/mixed_test.go:11\n", projectRoot)},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "\n",},
+				{Action: "output", Test: "level 1 A test 1 fails", Output: "To reproduce: go test github.com/matt-royal/biloba/test_assets/mixed -run '^TestMixed$' -ginkgo.focus='^Mixed Suite \\[Top Level\\] level 1 A test 1 fails$' -ginkgo.seed=1234\n"},
+				{Action: "output", Test: "level 1 A test 1 fails", Output: "\n"},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "--- FAIL: level 1 A test 1 fails (TIME)\n",},
 				{Action: "output", Test: "level 1 A test 1 fails", Output: "\n",},
 				{Action: "fail", Test: "level 1 A test 1 fails", Output: "\n",},
@@ -280,6 +333,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[2]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 A test 2 passes", Output: "\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "=== RUN   level 1 A test 2 passes\n",},
+				{Action: "output", Test: "level 1 A test 2 passes", Output: "# spec id: 5d477a9530c0ccc9\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "•\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "--- PASS: level 1 A test 2 passes (TIME)\n",},
 				{Action: "output", Test: "level 1 A test 2 passes", Output: "\n",},
@@ -289,6 +343,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[3]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 B test 1 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "=== RUN   level 1 B test 1 fails\n",},
+				{Action: "output", Test: "level 1 B test 1 fails", Output: "# spec id: 2599825eb7c0dfb6\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "• Failure [TIME]\n",},
@@ -307,6 +362,8 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 				{Action: "output", Test: "level 1 B test 1 fails", Output: fmt.Sprintf("    %s/test_assets/mixed/mixed_test.go:21\n", projectRoot)},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "------------------------------\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n",},
+				{Action: "output", Test: "level 1 B test 1 fails", Output: "To reproduce: go test github.com/matt-royal/biloba/test_assets/mixed -run '^TestMixed$' -ginkgo.focus='^Mixed Suite \\[Top Level\\] level 1 B test 1 fails$' -ginkgo.seed=1234\n"},
+				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n"},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "--- FAIL: level 1 B test 1 fails (TIME)\n",},
 				{Action: "output", Test: "level 1 B test 1 fails", Output: "\n",},
 				{Action: "fail", Test: "level 1 B test 1 fails", Output: "\n",},
@@ -315,6 +372,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[4]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "level 1 B test 2 passes", Output: "\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "=== RUN   level 1 B test 2 passes\n",},
+				{Action: "output", Test: "level 1 B test 2 passes", Output: "# spec id: 8efd78eda7e7db8c\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "•\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "--- PASS: level 1 B test 2 passes (TIME)\n",},
 				{Action: "output", Test: "level 1 B test 2 passes", Output: "\n",},
@@ -364,6 +422,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[1]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "FORMATTING this \\(level) has parenthesis test 1 passes", Output: "\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 1 passes", Output: "=== RUN   FORMATTING this \\(level) has parenthesis test 1 passes\n",},
+				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 1 passes", Output: "# spec id: 2ca727e5bcfc7474\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 1 passes", Output: "•\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 1 passes", Output: "--- PASS: FORMATTING this \\(level) has parenthesis test 1 passes (TIME)\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 1 passes", Output: "\n",},
@@ -373,6 +432,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[2]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "FORMATTING this \\(level) has parenthesis test 2 passes", Output: "\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 2 passes", Output: "=== RUN   FORMATTING this \\(level) has parenthesis test 2 passes\n",},
+				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 2 passes", Output: "# spec id: 95431104b8693a0d\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 2 passes", Output: "•\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 2 passes", Output: "--- PASS: FORMATTING this \\(level) has parenthesis test 2 passes (TIME)\n",},
 				{Action: "output", Test: "FORMATTING this \\(level) has parenthesis test 2 passes", Output: "\n",},
@@ -382,6 +442,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[3]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "FORMATTING this /level/ has slashes test 1 passes", Output: "\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 1 passes", Output: "=== RUN   FORMATTING this /level/ has slashes test 1 passes\n",},
+				{Action: "output", Test: "FORMATTING this /level/ has slashes test 1 passes", Output: "# spec id: 762b7b3ea999bdb5\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 1 passes", Output: "•\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 1 passes", Output: "--- PASS: FORMATTING this /level/ has slashes test 1 passes (TIME)\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 1 passes", Output: "\n",},
@@ -391,6 +452,7 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			Expect(groups[4]).To(Equal([]testJsonEntry{
 				{Action: "run", Test: "FORMATTING this /level/ has slashes test 2 passes", Output: "\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 2 passes", Output: "=== RUN   FORMATTING this /level/ has slashes test 2 passes\n",},
+				{Action: "output", Test: "FORMATTING this /level/ has slashes test 2 passes", Output: "# spec id: 9f096bd2f01b1011\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 2 passes", Output: "•\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 2 passes", Output: "--- PASS: FORMATTING this /level/ has slashes test 2 passes (TIME)\n",},
 				{Action: "output", Test: "FORMATTING this /level/ has slashes test 2 passes", Output: "\n",},
@@ -408,6 +470,848 @@ var _ = Describe("GoTestCompatibleReporter", func() {
 			}))
 		})
 	})
+
+	When("a spec only passes on retry", func() {
+		var reportFile string
+
+		BeforeEach(func() {
+			reportFile = filepath.Join(tempDir, "report.json")
+		})
+
+		It("reports each attempt and marks the spec as flaky", func() {
+			lines := testOutputLinesWithEnv("./test_assets/flaky", []string{"BILOBA_REPORT_FILE=" + reportFile}, "-ginkgo.flakeAttempts=2")
+			groups := groupByTest(lines)
+
+			Expect(groups).To(HaveLen(5))
+			Expect(groups[1][0]).To(Equal(testJsonEntry{Action: "run", Test: "level 1 test 1 passes on retry", Output: "\n"}))
+			Expect(groups[1][len(groups[1])-1].Action).To(Equal("fail"))
+
+			Expect(groups[2]).To(Equal([]testJsonEntry{
+				{Action: "run", Test: "level 1 test 1 passes on retry#attempt2", Output: "\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "=== RUN   level 1 test 1 passes on retry#attempt2\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "# spec id: 951f66218562f285\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "•\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "FLAKY: level 1 test 1 passes on retry passed on attempt 2 of 2\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "--- PASS: level 1 test 1 passes on retry#attempt2 (TIME)\n"},
+				{Action: "output", Test: "level 1 test 1 passes on retry#attempt2", Output: "\n"},
+				{Action: "pass", Test: "level 1 test 1 passes on retry#attempt2", Output: "\n"},
+			}))
+
+			Expect(groups[4]).To(ContainElement(
+				testJsonEntry{Action: "output", Test: "TestFlaky", Output: "Flaky specs (passed on retry):\n"},
+			))
+			Expect(groups[4]).To(ContainElement(
+				testJsonEntry{Action: "output", Test: "TestFlaky", Output: "    level 1 test 1 passes on retry (attempt 2)\n"},
+			))
+
+			report, err := biloba.ReadReport(reportFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Specs).To(HaveLen(2))
+			Expect(report.Specs[0].Name).To(Equal("level 1 test 1 passes on retry"))
+			Expect(report.Specs[0].State).To(Equal("passed"))
+			Expect(report.Specs[0].Attempts).To(Equal(2))
+			Expect(report.Specs[0].ID).To(Equal("951f66218562f285"))
+			Expect(report.Specs[0].Flaky).To(BeTrue())
+			Expect(report.Specs[1].Flaky).To(BeFalse())
+		})
+	})
+
+	When("the suite has measurements", func() {
+		It("outputs them as go benchmark results", func() {
+			lines := testOutputLines("./test_assets/measure")
+
+			var outputs []string
+			var benches []string
+			for _, line := range lines {
+				switch line.Action {
+				case "output":
+					outputs = append(outputs, line.Output)
+				case "bench":
+					benches = append(benches, line.Test)
+				}
+			}
+
+			Expect(outputs).To(ContainElement(MatchRegexp(`^BenchmarkMeasureSuite/level_1/measures_x/runtime\t +3\t\d+(\.\d+)? ns/op\n$`)))
+			Expect(outputs).To(ContainElement("BenchmarkMeasureSuite/level_1/measures_x/disk_usage\t       3\t12 MB\n"))
+			Expect(benches).To(Equal([]string{
+				"BenchmarkMeasureSuite/level_1/measures_x/runtime",
+				"BenchmarkMeasureSuite/level_1/measures_x/disk_usage",
+			}))
+		})
+	})
+
+	When("a spec is still running at the go test timeout", func() {
+		var reportFile string
+
+		BeforeEach(func() {
+			reportFile = filepath.Join(tempDir, "report.json")
+		})
+
+		It("fails the running spec with a goroutine dump and flushes the reports", func() {
+			lines := testOutputLinesWithEnv("./test_assets/hanging", []string{"BILOBA_REPORT_FILE=" + reportFile}, "-test.timeout=2s")
+
+			var actions []string
+			for _, line := range lines {
+				if line.Test == "level 1 test 1 hangs" && line.Action != "output" {
+					actions = append(actions, line.Action)
+				}
+			}
+			// test2json attributes the package's final FAIL to the last test it saw
+			Expect(actions).To(Equal([]string{"run", "fail", "fail"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 hangs", Output: "Spec timed out. Goroutine dump:\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 hangs", Output: "--- FAIL: level 1 test 1 hangs (TIME)\n"}))
+
+			report, err := biloba.ReadReport(reportFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Succeeded).To(BeFalse())
+			Expect(report.Specs).To(HaveLen(1))
+			Expect(report.Specs[0].State).To(Equal("failed"))
+			Expect(report.Specs[0].Failure).To(Equal("spec timed out"))
+		})
+	})
+
+	When("the suite runs on parallel nodes", func() {
+		It("prints every spec from node 1 without interleaving", func() {
+			lines := parallelTestOutputLines("./test_assets/mixed", 2)
+
+			var specs []string
+			for _, group := range groupByTest(lines) {
+				name := group[0].Test
+				if name == "TestMixed" || name == "" {
+					continue
+				}
+				specs = append(specs, name)
+
+				state := "PASS"
+				if strings.HasSuffix(name, "fails") {
+					state = "FAIL"
+				}
+				Expect(group[0].Action).To(Equal("run"))
+				Expect(group[1]).To(Equal(testJsonEntry{Action: "output", Test: name, Output: "=== RUN   " + name + "\n"}))
+				Expect(group).To(ContainElement(
+					testJsonEntry{Action: "output", Test: name, Output: "--- " + state + ": " + name + " (TIME)\n"},
+				))
+			}
+
+			Expect(specs).To(ConsistOf(
+				"level 1 A test 1 fails",
+				"level 1 A test 2 passes",
+				"level 1 B test 1 fails",
+				"level 1 B test 2 passes",
+			))
+		})
+	})
+
+	When("rerunning failed specs", func() {
+		var failedSpecsFile string
+
+		BeforeEach(func() {
+			failedSpecsFile = filepath.Join(tempDir, "failed.json")
+		})
+
+		It("only runs the specs that failed in the previous run", func() {
+			env := []string{"BILOBA_FAILED_SPECS_FILE=" + failedSpecsFile}
+			Expect(testOutputLinesWithEnv("./test_assets/mixed", env)).To(ContainElement(
+				testJsonEntry{Action: "output", Test: "TestMixed", Output: "Will run 4 of 4 specs\n"},
+			))
+
+			lines := testOutputLinesWithEnv("./test_assets/mixed", append(env, "BILOBA_RERUN_FAILED=1"))
+			Expect(lines).To(ContainElement(
+				testJsonEntry{Action: "output", Test: "TestMixed", Output: "Will run 2 of 4 specs\n"},
+			))
+
+			var skipped []string
+			for _, line := range lines {
+				if line.Action == "skip" {
+					skipped = append(skipped, line.Test)
+				}
+			}
+			Expect(skipped).To(ConsistOf("level 1 A test 2 passes", "level 1 B test 2 passes"))
+		})
+	})
+
+	When("a quarantined spec fails", func() {
+		var junitFile string
+
+		BeforeEach(func() {
+			junitFile = filepath.Join(tempDir, "junit.xml")
+		})
+
+		It("skips it instead of failing the suite and marks it as quarantined", func() {
+			lines := testOutputLinesWithEnv("./test_assets/quarantine", []string{"BILOBA_JUNIT_FILE=" + junitFile})
+
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 is quarantined", Output: "QUARANTINED (owner: team-a, ticket: BUG-1): Expected\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 is quarantined", Output: "--- SKIP: level 1 test 1 is quarantined (TIME)\n"}))
+			Expect(lines[len(lines)-1].Action).To(Equal("pass"))
+
+			junit, err := ioutil.ReadFile(junitFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(junit)).To(ContainSubstring(`<testsuite name="Quarantine Suite" tests="2" failures="0" skipped="1"`))
+			Expect(string(junit)).To(ContainSubstring(`<property name="id" value="2b0c85f10c93fe8a"></property>`))
+			Expect(string(junit)).To(MatchRegexp(`<property name="location" value="\S+quarantine_test.go:\d+"></property>`))
+			Expect(string(junit)).To(ContainSubstring(`<property name="quarantine.owner" value="team-a"></property>`))
+			Expect(string(junit)).To(ContainSubstring(`<skipped message="quarantined failure">`))
+		})
+	})
+
+	When("specs are expected to fail", func() {
+		var junitFile string
+
+		BeforeEach(func() {
+			junitFile = filepath.Join(tempDir, "junit.xml")
+		})
+
+		It("reports expected failures as passing and unexpected passes as failing", func() {
+			lines := testOutputLinesWithEnv("./test_assets/xfail", []string{"BILOBA_JUNIT_FILE=" + junitFile})
+
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 fails as expected", Output: "EXPECTED FAILURE: Expected\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 fails as expected", Output: "--- PASS: level 1 test 1 fails as expected (TIME)\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 3 fails as expected [xfail]", Output: "--- PASS: level 1 test 3 fails as expected [xfail] (TIME)\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 2 passes unexpectedly", Output: "--- FAIL: level 1 test 2 passes unexpectedly (TIME)\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "pass", Test: "level 1 test 1 fails as expected", Output: "\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "fail", Test: "level 1 test 2 passes unexpectedly", Output: "\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 4 passes unexpectedly [xfail]", Output: "--- FAIL: level 1 test 4 passes unexpectedly [xfail] (TIME)\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 5 is skipped [xfail]", Output: "--- SKIP: level 1 test 5 is skipped [xfail] (TIME)\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 5 is skipped [xfail]", Output: "FAIL! -- 0 Passed | 2 Failed | 0 Pending | 3 Skipped\n"}))
+
+			junit, err := ioutil.ReadFile(junitFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(junit)).To(ContainSubstring(`<testsuite name="XFail Suite" tests="5" failures="2" skipped="1"`))
+			Expect(string(junit)).To(ContainSubstring(`<property name="xfail" value="expected failure"></property>`))
+			Expect(string(junit)).To(ContainSubstring(`<property name="xfail" value="unexpected pass"></property>`))
+		})
+	})
+
+	When("specs are slower than the slow spec threshold", func() {
+		var reportFile string
+
+		BeforeEach(func() {
+			reportFile = filepath.Join(tempDir, "report.json")
+		})
+
+		It("marks them and lists the slowest specs at the end", func() {
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_REPORT_FILE=" + reportFile}, "-ginkgo.slowSpecThreshold=0.2")
+
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 is slow", Output: "SLOW: level 1 test 1 is slow took longer than TIME\n"}))
+			Expect(lines).NotTo(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 2 is fast", Output: "SLOW: level 1 test 2 is fast took longer than TIME\n"}))
+
+			var suiteOutputs []string
+			for _, line := range lines {
+				if line.Test == "TestSlow" {
+					suiteOutputs = append(suiteOutputs, line.Output)
+				}
+			}
+			Expect(suiteOutputs).To(ContainElement("Slowest specs (over TIME):\n"))
+			Expect(suiteOutputs).To(ContainElement(MatchRegexp(`^    TIME  level 1 test 1 is slow  .*/test_assets/slow/slow_test.go:10\n$`)))
+
+			report, err := biloba.ReadReport(reportFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Specs).To(HaveLen(2))
+			Expect(report.Specs[0].Slow).To(BeTrue())
+			Expect(report.Specs[1].Slow).To(BeFalse())
+		})
+
+		It("prints durations with zero-padded milliseconds", func() {
+			cmd := exec.Command("go", "test", "-v", "./test_assets/slow", "-args", "-ginkgo.noColor", "-ginkgo.slowSpecThreshold=0.05")
+			cmd.Env = append(os.Environ(), "BILOBA_INTEGRATION_TEST=true")
+			output, err := cmd.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+
+			Expect(string(output)).To(ContainSubstring("SLOW: level 1 test 1 is slow took longer than 0.050s\n"))
+			Expect(string(output)).To(ContainSubstring("Slowest specs (over 0.050s):\n"))
+			Expect(string(output)).To(MatchRegexp(`--- PASS: level 1 test 2 is fast \(0\.0\d\ds\)`))
+		})
+	})
+
+	When("specs leak goroutines", func() {
+		It("prints the leaked goroutines and fails the specs that verify there are none", func() {
+			lines := testOutputLines("./test_assets/leaky")
+
+			var outputs []string
+			for _, line := range lines {
+				if line.Test == "level 1 test 1 leaks a goroutine" {
+					outputs = append(outputs, line.Output)
+				}
+			}
+			Expect(outputs).To(ContainElement("LEAKED GOROUTINES: 1 goroutine(s) started by the spec are still running:\n"))
+			Expect(outputs).To(ContainElement(HavePrefix("github.com/matt-royal/biloba/test_assets/leaky_test.leakyWorker(")))
+			Expect(outputs).To(ContainElement("--- FAIL: level 1 test 1 leaks a goroutine (TIME)\n"))
+
+			for _, line := range lines {
+				if line.Test == "level 1 test 2 stops its goroutine" || line.Test == "level 1 test 3 leaks an ignored goroutine" {
+					Expect(line.Output).NotTo(HavePrefix("LEAKED GOROUTINES"))
+					Expect(line.Output).NotTo(HavePrefix("--- FAIL"))
+				}
+			}
+		})
+	})
+
+	When("resource usage is collected", func() {
+		var reportFile string
+
+		BeforeEach(func() {
+			reportFile = filepath.Join(tempDir, "report.json")
+		})
+
+		It("prints the usage of each spec and includes it in the report", func() {
+			lines := testOutputLinesWithEnv("./test_assets/resources", []string{"BILOBA_REPORT_FILE=" + reportFile})
+
+			usages := map[string]string{}
+			for _, line := range lines {
+				if strings.HasPrefix(line.Output, "RESOURCES: ") {
+					usages[line.Test] = line.Output
+				}
+			}
+			Expect(usages).To(HaveLen(2))
+			for _, usage := range usages {
+				Expect(usage).To(MatchRegexp(`^RESOURCES: wall TIME, cpu TIME \(user TIME, sys TIME\), \d+ allocs, [\d.]+ [KMG]?i?B allocated, heap [\d.]+ [KMG]?i?B\n$`))
+			}
+
+			report, err := biloba.ReadReport(reportFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Specs).To(HaveLen(2))
+			Expect(report.Specs[0].Resources.AllocatedBytes).To(BeNumerically(">=", 10<<20))
+			Expect(report.Specs[1].Resources.WallTime).To(BeNumerically(">=", 200*time.Millisecond))
+			Expect(report.Specs[1].Resources.UserTime + report.Specs[1].Resources.SystemTime).To(BeNumerically(">", 100*time.Millisecond))
+		})
+	})
+
+	When("slow specs are profiled", func() {
+		var profileDir string
+
+		BeforeEach(func() {
+			profileDir = filepath.Join(tempDir, "profiles")
+		})
+
+		It("keeps the profiles of the slow specs and references them in the output", func() {
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_PROFILE_DIR=" + profileDir})
+
+			profiled := map[string]string{}
+			for _, line := range lines {
+				if strings.HasPrefix(line.Output, "PROFILES: ") {
+					profiled[line.Test] = line.Output
+				}
+			}
+			Expect(profiled).To(HaveLen(1))
+			cpuProfile := filepath.Join(profileDir, "43b41c17ce4490aa.cpu.pprof")
+			heapProfile := filepath.Join(profileDir, "43b41c17ce4490aa.heap.pprof")
+			Expect(profiled).To(HaveKeyWithValue("level 1 test 1 is slow", fmt.Sprintf("PROFILES: cpu %s, heap %s\n", cpuProfile, heapProfile)))
+
+			files, err := ioutil.ReadDir(profileDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(2))
+			Expect(cpuProfile).To(BeAnExistingFile())
+			Expect(heapProfile).To(BeAnExistingFile())
+		})
+	})
+
+	When("the progress reporter is used without a terminal", func() {
+		var durationsFile string
+
+		BeforeEach(func() {
+			durationsFile = filepath.Join(tempDir, "durations.json")
+		})
+
+		It("prints a progress line per spec and records the durations for the next estimate", func() {
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_DURATIONS_FILE=" + durationsFile})
+
+			var outputs []string
+			for _, line := range lines {
+				outputs = append(outputs, line.Output)
+			}
+			Expect(outputs).To(ContainElement(MatchRegexp(`\[1/2\] 0 failed \| 0:00 elapsed \| ETA (0:00|unknown) PASSED: level 1 test 1 is slow \(TIME\)\n$`)))
+			Expect(outputs).To(ContainElement(MatchRegexp(`\[2/2\] 0 failed \| 0:00 elapsed \| ETA 0:00 PASSED: level 1 test 2 is fast \(TIME\)\n$`)))
+
+			var durations biloba.SpecDurations
+			data, err := ioutil.ReadFile(durationsFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(data, &durations)).To(Succeed())
+			Expect(durations).To(HaveLen(2))
+			Expect(durations["43b41c17ce4490aa"]).To(BeNumerically(">=", 300*time.Millisecond))
+		})
+
+		It("drops the durations of specs that are gone and keeps those of skipped specs", func() {
+			Expect(ioutil.WriteFile(durationsFile, []byte(`{"0000000000000000": 600000000000, "43b41c17ce4490aa": 300000000}`), 0644)).To(Succeed())
+
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_DURATIONS_FILE=" + durationsFile}, "-ginkgo.skip='test 1'")
+
+			var outputs []string
+			for _, line := range lines {
+				outputs = append(outputs, line.Output)
+			}
+			Expect(outputs).To(ContainElement(MatchRegexp(`\[1/1\] 0 failed \| 0:00 elapsed \| ETA 0:00 PASSED: level 1 test 2 is fast \(TIME\)\n$`)))
+
+			var durations biloba.SpecDurations
+			data, err := ioutil.ReadFile(durationsFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(data, &durations)).To(Succeed())
+			Expect(durations).To(HaveLen(2))
+			Expect(durations).NotTo(HaveKey("0000000000000000"))
+			Expect(durations).To(HaveKeyWithValue("43b41c17ce4490aa", 300*time.Millisecond))
+		})
+
+		It("keeps redrawing the status line on a terminal while a spec runs", func() {
+			if runtime.GOOS != "linux" {
+				Skip("needs util-linux script to run the suite on a terminal")
+			}
+			testBinary := filepath.Join(tempDir, "hanging.test")
+			Expect(exec.Command("go", "test", "-c", "-o", testBinary, "./test_assets/hanging").Run()).To(Succeed())
+
+			cmd := exec.Command("script", "-qec", testBinary+" -ginkgo.noColor -test.timeout=3s", "/dev/null")
+			cmd.Env = append(os.Environ(), "BILOBA_INTEGRATION_TEST=true", "BILOBA_DURATIONS_FILE="+durationsFile)
+			output, _ := cmd.CombinedOutput()
+
+			Expect(string(output)).To(ContainSubstring("\r\033[K[0/1] 0 failed | 0:01 elapsed | ETA unknown | level 1 test 1 hangs"))
+			Expect(string(output)).To(ContainSubstring("\r\033[K[0/1] 0 failed | 0:02 elapsed | ETA unknown | level 1 test 1 hangs"))
+		})
+	})
+
+	When("the hang watchdog is on", func() {
+		It("reports the running spec and dumps the goroutines at intervals", func() {
+			lines := testOutputLinesWithEnv("./test_assets/hanging", []string{"BILOBA_WATCH_FOR_HANGS=true"}, "-test.timeout=3s")
+
+			var output []string
+			for _, line := range lines {
+				if line.Action == "output" && line.Test == "level 1 test 1 hangs" {
+					output = append(output, line.Output)
+				}
+			}
+
+			hangs := 0
+			for i, line := range output {
+				if strings.HasPrefix(line, "HANG: level 1 test 1 hangs has been running for ") {
+					Expect(line).To(HaveSuffix(". Goroutine dump:\n"))
+					Expect(output[i+2]).To(HavePrefix("goroutine "))
+					hangs++
+				}
+			}
+			Expect(hangs).To(BeNumerically(">=", 2))
+		})
+	})
+
+	When("an event log is written", func() {
+		var eventLog string
+
+		BeforeEach(func() {
+			eventLog = filepath.Join(tempDir, "events.ndjson")
+		})
+
+		It("records every event with its full data", func() {
+			testOutputLinesWithEnv("./test_assets/measure", []string{"BILOBA_EVENT_LOG=" + eventLog})
+
+			file, err := os.Open(eventLog)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+			log, err := events.ReadAll(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			var types []events.Type
+			for _, event := range log {
+				Expect(event.Version).To(Equal(events.Version))
+				types = append(types, event.Type)
+			}
+			// ginkgo only reports BeforeSuite and AfterSuite when the suite has them
+			Expect(types).To(Equal([]events.Type{
+				events.SuiteWillBegin,
+				events.SpecWillRun,
+				events.SpecDidComplete,
+				events.SuiteDidEnd,
+			}))
+
+			Expect(log[0].Config.RandomSeed).To(Equal(int64(1234)))
+			Expect(log[0].Suite.SuiteDescription).To(Equal("Measure Suite"))
+			spec := log[2].Spec
+			Expect(log[2].SpecID).To(Equal(log[1].SpecID))
+			Expect(spec.ComponentTexts).To(Equal([]string{"[Top Level]", "level 1", "measures x"}))
+			Expect(spec.ComponentCodeLocations[2].FileName).To(HaveSuffix("test_assets/measure/measure_test.go"))
+			Expect(spec.Passed()).To(BeTrue())
+			Expect(spec.IsMeasurement).To(BeTrue())
+			Expect(spec.NumberOfSamples).To(Equal(3))
+			Expect(spec.Measurements).To(HaveKey("disk usage"))
+			Expect(spec.Measurements["disk usage"].Results).To(Equal([]float64{12, 12, 12}))
+			Expect(log[3].Suite.SuiteSucceeded).To(BeTrue())
+			Expect(log[3].Suite.NumberOfPassedSpecs).To(Equal(1))
+		})
+	})
+
+	When("a recorded run is replayed", func() {
+		It("reports it again through the chosen reporters", func() {
+			eventLog := filepath.Join(tempDir, "events.ndjson")
+			reportFile := filepath.Join(tempDir, "report.json")
+			testOutputLinesWithEnv("./test_assets/mixed", []string{"BILOBA_EVENT_LOG=" + eventLog})
+
+			// replay outside of the module, as on another machine
+			bilobaBinary := filepath.Join(tempDir, "biloba")
+			Expect(exec.Command("go", "build", "-o", bilobaBinary, "./cmd/biloba").Run()).To(Succeed())
+			replay := exec.Command("bash", "-c", fmt.Sprintf("%s replay -json %s %s | go tool test2json", bilobaBinary, reportFile, eventLog))
+			replay.Dir = tempDir
+			lines := parseTestJson(replay)
+
+			var results, reproduce []string
+			for _, line := range lines {
+				if line.Action == "output" && strings.HasPrefix(line.Output, "--- ") {
+					results = append(results, line.Output)
+				}
+				if line.Action == "output" && strings.HasPrefix(line.Output, "To reproduce: ") {
+					reproduce = append(reproduce, line.Output)
+				}
+			}
+			Expect(reproduce).To(ContainElement("To reproduce: go test github.com/matt-royal/biloba/test_assets/mixed -run '^TestMixed$' -ginkgo.focus='^Mixed Suite \\[Top Level\\] level 1 A test 1 fails$' -ginkgo.seed=1234\n"))
+			Expect(results).To(Equal([]string{
+				"--- FAIL: level 1 A test 1 fails (TIME)\n",
+				"--- PASS: level 1 A test 2 passes (TIME)\n",
+				"--- FAIL: level 1 B test 1 fails (TIME)\n",
+				"--- PASS: level 1 B test 2 passes (TIME)\n",
+			}))
+
+			report, err := biloba.ReadReport(reportFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Suite).To(Equal("Mixed Suite"))
+			Expect(report.Succeeded).To(BeFalse())
+			Expect(report.Specs).To(HaveLen(4))
+			Expect(report.Specs[0].Name).To(Equal("level 1 A test 1 fails"))
+			Expect(report.Specs[0].State).To(Equal("failed"))
+			Expect(report.Specs[0].Failure).To(HavePrefix("Expected"))
+
+			file, err := os.Open(eventLog)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+			log, err := events.ReadAll(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log[0].Package).To(Equal("github.com/matt-royal/biloba/test_assets/mixed"))
+			Expect(log[0].TestFunc).To(Equal("TestMixed"))
+			Expect(report.Specs[0].ID).To(Equal(log[2].SpecID))
+		})
+
+		It("prints the same spec output as the recorded run", func() {
+			eventLog := filepath.Join(tempDir, "events.ndjson")
+			live := testOutputLinesWithEnv("./test_assets/mixed", []string{"BILOBA_EVENT_LOG=" + eventLog})
+
+			bilobaBinary := filepath.Join(tempDir, "biloba")
+			Expect(exec.Command("go", "build", "-o", bilobaBinary, "./cmd/biloba").Run()).To(Succeed())
+			replay := exec.Command("bash", "-c", fmt.Sprintf("%s replay -default -noColor %s | go tool test2json", bilobaBinary, eventLog))
+			replayed := parseTestJson(replay)
+
+			// test2json only reports the result of the last spec once the go test
+			// function ends, which has no output in a replay
+			specOutput := func(lines []testJsonEntry) []testJsonEntry {
+				var specLines []testJsonEntry
+				for _, line := range lines {
+					if line.Action == "output" && line.Test != "" && line.Test != "TestMixed" {
+						specLines = append(specLines, line)
+					}
+				}
+				return specLines
+			}
+			Expect(specOutput(live)).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 A test 1 fails", Output: "--- FAIL: level 1 A test 1 fails (TIME)\n"}))
+			Expect(specOutput(replayed)).To(Equal(specOutput(live)))
+		})
+	})
+
+	When("the suite subscribes to events", func() {
+		It("calls the subscriber with each event", func() {
+			lines := testOutputLines("./test_assets/subscribe")
+
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 1 fails", Output: "subscriber saw test 1 fails fail\n"}))
+			Expect(lines).To(ContainElement(testJsonEntry{Action: "output", Test: "level 1 test 2 passes", Output: "subscriber saw 1 of 2 specs pass\n"}))
+		})
+	})
+
+	When("an HTML report is written", func() {
+		var htmlFile string
+
+		BeforeEach(func() {
+			htmlFile = filepath.Join(tempDir, "report.html")
+		})
+
+		It("writes a self-contained page with the tree of specs", func() {
+			testOutputLinesWithEnv("./test_assets/mixed", []string{"BILOBA_HTML_FILE=" + htmlFile})
+
+			data, err := ioutil.ReadFile(htmlFile)
+			Expect(err).NotTo(HaveOccurred())
+			page := string(data)
+			Expect(page).To(ContainSubstring("<title>Mixed Suite</title>"))
+			Expect(page).To(ContainSubstring("<span>2 passed</span>"))
+			Expect(page).To(ContainSubstring("<span>2 failed</span>"))
+			Expect(page).To(ContainSubstring("<summary>level 1</summary>"))
+			Expect(page).To(ContainSubstring("<summary>A</summary>"))
+			Expect(page).To(ContainSubstring(`<details class="spec failed" data-state="failed" data-name="level 1 A test 1 fails" open>`))
+			Expect(page).To(ContainSubstring(`<details class="spec passed" data-state="passed" data-name="level 1 A test 2 passes">`))
+			Expect(page).To(MatchRegexp(`<pre class="failure">Expected\n    &lt;bool&gt;: true\nto equal\n    &lt;bool&gt;: false\n.*/test_assets/mixed/mixed_test.go:11</pre>`))
+			Expect(page).NotTo(MatchRegexp(`(src|href)="(https?:)?//`))
+		})
+	})
+
+	When("a Markdown summary is written", func() {
+		var markdownFile string
+
+		BeforeEach(func() {
+			markdownFile = filepath.Join(tempDir, "summary.md")
+		})
+
+		It("summarizes the totals, failures and slowest specs", func() {
+			testOutputLinesWithEnv("./test_assets/mixed", []string{"BILOBA_MARKDOWN_FILE=" + markdownFile})
+
+			data, err := ioutil.ReadFile(markdownFile)
+			Expect(err).NotTo(HaveOccurred())
+			summary := standardizeTime(string(data))
+			Expect(summary).To(HavePrefix("# Mixed Suite\n\n**Failed** in TIME\n\n"))
+			Expect(summary).To(ContainSubstring("| Passed | Failed | Skipped | Pending | Flaky | Total |\n| ---: | ---: | ---: | ---: | ---: | ---: |\n| 2 | 2 | 0 | 0 | 0 | 4 |\n"))
+			Expect(summary).To(MatchRegexp("\n<details>\n<summary><code>level 1 A test 1 fails</code></summary>\n\n```\nExpected\n    <bool>: true\nto equal\n    <bool>: false\n.*/test_assets/mixed/mixed_test.go:11\n```\n\n</details>\n"))
+			Expect(summary).To(ContainSubstring("## Slowest specs\n\n| Duration | Spec | Location |\n"))
+			Expect(summary).To(MatchRegexp(`\| TIME \| level 1 B test 2 passes \| .*/test_assets/mixed/mixed_test.go:24 \|\n`))
+		})
+	})
+
+	When("the run history is recorded", func() {
+		var historyDir string
+
+		BeforeEach(func() {
+			historyDir = filepath.Join(tempDir, "history")
+		})
+
+		It("reports flaky and newly failing specs across runs", func() {
+			env := []string{"BILOBA_HISTORY_DIR=" + historyDir}
+			testOutputLinesWithEnv("./test_assets/flaky", env, "-ginkgo.flakeAttempts=2")
+			testOutputLinesWithEnv("./test_assets/flaky", env)
+
+			runs, err := biloba.ReadHistory(historyDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runs).To(HaveLen(2))
+			Expect(runs[0].Suite).To(Equal("Flaky Suite"))
+			Expect(runs[0].Succeeded).To(BeTrue())
+			Expect(runs[1].Succeeded).To(BeFalse())
+			Expect(runs[0].Specs).To(HaveLen(2))
+			Expect(runs[0].Specs[0].ID).To(Equal("951f66218562f285"))
+			Expect(runs[0].Specs[0].Flaky).To(BeTrue())
+			Expect(runs[1].Specs[0].State).To(Equal("failed"))
+
+			flakiness := biloba.Flakiness(runs)
+			Expect(flakiness).To(HaveLen(1))
+			Expect(flakiness[0].Name).To(Equal("level 1 test 1 passes on retry"))
+			Expect(flakiness[0].Runs).To(Equal(2))
+			Expect(flakiness[0].Flaky).To(Equal(1))
+			Expect(flakiness[0].Flips).To(Equal(1))
+			Expect(flakiness[0].Rate()).To(Equal(1.0))
+
+			Expect(biloba.DurationTrends(runs)).To(HaveLen(2))
+
+			output, err := exec.Command("go", "run", "./cmd/biloba", "history", "-dir", historyDir, "new-failures").CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			Expect(string(output)).To(MatchRegexp(`(?m)^STATE\s+SPEC\s+LOCATION$`))
+			Expect(string(output)).To(MatchRegexp(`(?m)^failed\s+level 1 test 1 passes on retry\s+\S+flaky_test.go:11$`))
+			Expect(string(output)).NotTo(ContainSubstring("test 2"))
+
+			output, err = exec.Command("go", "run", "./cmd/biloba", "history", "-dir", historyDir, "flaky").CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			Expect(string(output)).To(MatchRegexp(`(?m)^100%\s+2\s+1\s+1\s+1\s+level 1 test 1 passes on retry\s`))
+		})
+
+		It("records an interrupted run once", func() {
+			testBinary := filepath.Join(tempDir, "hanging.test")
+			Expect(exec.Command("go", "test", "-c", "-o", testBinary, "./test_assets/hanging").Run()).To(Succeed())
+
+			cmd := exec.Command(testBinary, "-ginkgo.noColor")
+			cmd.Env = append(os.Environ(), "BILOBA_INTEGRATION_TEST=true", "BILOBA_HISTORY_DIR="+historyDir)
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			time.Sleep(time.Second)
+			session.Interrupt()
+			Eventually(session, 10*time.Second).Should(gexec.Exit())
+
+			runs, err := biloba.ReadHistory(historyDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].Succeeded).To(BeFalse())
+			Expect(runs[0].Specs).To(HaveLen(1))
+			Expect(runs[0].Specs[0].State).To(Equal("failed"))
+		})
+	})
+
+	When("spec durations are compared with a baseline", func() {
+		It("reports the specs that got slower and fails the suite", func() {
+			baselineFile := filepath.Join(tempDir, "baseline.json")
+			testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_REPORT_FILE=" + baselineFile})
+			baseline, err := biloba.ReadReport(baselineFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(baseline.Specs[0].Name).To(Equal("level 1 test 1 is slow"))
+			baseline.Specs[0].RunTime = 50 * time.Millisecond
+			contents, err := json.Marshal(baseline)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(baselineFile, contents, 0644)).To(Succeed())
+
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_DURATION_BASELINE=" + baselineFile})
+
+			var output []string
+			var action string
+			for _, line := range lines {
+				if line.Test != "TestSlow" {
+					continue
+				}
+				if line.Action == "output" {
+					output = append(output, line.Output)
+				} else {
+					action = line.Action
+				}
+			}
+			Expect(strings.Join(output, "")).To(ContainSubstring("\nDuration regressions against " + baselineFile + " (over 2.00x and 100ms slower):\n"))
+			Expect(strings.Join(output, "")).To(MatchRegexp(`\n    TIME  was TIME  \d+\.\d\dx  level 1 test 1 is slow  \S+slow_test.go:10\n`))
+			Expect(strings.Join(output, "")).NotTo(ContainSubstring("test 2 is fast  "))
+			Expect(action).To(Equal("fail"))
+		})
+
+		It("doesn't fail the suite without a baseline", func() {
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_DURATION_BASELINE=" + filepath.Join(tempDir, "missing.json")})
+
+			for _, line := range lines {
+				Expect(line.Output).NotTo(ContainSubstring("Duration regressions"))
+				Expect(line.Action).NotTo(Equal("fail"))
+			}
+		})
+	})
+
+	When("the suite has a time budget", func() {
+		testOutput := func(budget string) (string, string) {
+			lines := testOutputLinesWithEnv("./test_assets/slow", []string{"BILOBA_TIME_BUDGET=" + budget})
+
+			var output []string
+			var action string
+			for _, line := range lines {
+				if line.Test != "TestSlow" {
+					continue
+				}
+				if line.Action == "output" {
+					output = append(output, line.Output)
+				} else {
+					action = line.Action
+				}
+			}
+			return strings.Join(output, ""), action
+		}
+
+		It("fails the suite with the largest contributors when it is exceeded", func() {
+			output, action := testOutput("100ms")
+
+			Expect(output).To(ContainSubstring("\nTIME BUDGET EXCEEDED: Slow Suite took TIME, over its budget of TIME\nLargest contributors:\n"))
+			Expect(output).To(MatchRegexp(`\n    TIME  \d+%  level 1\n`))
+			Expect(output).To(MatchRegexp(`Slowest specs:\n    TIME  level 1 test 1 is slow  \S+slow_test.go:10\n    TIME  level 1 test 2 is fast  \S+slow_test.go:14\n`))
+			Expect(output).To(ContainSubstring("\nTIME BUDGET EXCEEDED: level 1 took TIME, over its budget of TIME\nSlowest specs:\n"))
+			Expect(action).To(Equal("fail"))
+		})
+
+		It("passes within the budget", func() {
+			output, action := testOutput("1m")
+
+			Expect(output).NotTo(ContainSubstring("TIME BUDGET EXCEEDED"))
+			Expect(action).To(Equal("pass"))
+		})
+	})
+
+	When("two reports are compared", func() {
+		It("lists the specs that changed", func() {
+			oldFile := filepath.Join(tempDir, "old.xml")
+			Expect(ioutil.WriteFile(oldFile, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Suite" tests="6" failures="1" skipped="0" time="1">
+  <testcase name="a starts failing" classname="Suite" time="0.1">
+    <properties><property name="id" value="a"></property><property name="location" value="suite_test.go:1"></property></properties>
+  </testcase>
+  <testcase name="b starts passing" classname="Suite" time="0.1">
+    <properties><property name="id" value="b"></property><property name="location" value="suite_test.go:2"></property></properties>
+    <failure type="failed" message="failed">Expected</failure>
+  </testcase>
+  <testcase name="d is removed" classname="Suite" time="0.1">
+    <properties><property name="id" value="d"></property><property name="location" value="suite_test.go:4"></property></properties>
+  </testcase>
+  <testcase name="e is renamed" classname="Suite" time="0.1">
+    <properties><property name="id" value="e"></property><property name="location" value="suite_test.go:5"></property></properties>
+  </testcase>
+  <testcase name="f gets slower" classname="Suite" time="0.1">
+    <properties><property name="id" value="f"></property><property name="location" value="suite_test.go:6"></property></properties>
+  </testcase>
+  <testcase name="g stays the same" classname="Suite" time="0.1">
+    <properties><property name="id" value="g"></property><property name="location" value="suite_test.go:7"></property></properties>
+  </testcase>
+</testsuite>
+`), 0644)).To(Succeed())
+			newFile := filepath.Join(tempDir, "new.json")
+			Expect(ioutil.WriteFile(newFile, []byte(`{
+				"suite": "Suite",
+				"specs": [
+					{"id": "a", "name": "a starts failing", "location": "suite_test.go:1", "state": "failed", "run_time": 100000000},
+					{"id": "b", "name": "b starts passing", "location": "suite_test.go:2", "state": "passed", "run_time": 100000000},
+					{"id": "c", "name": "c is added", "location": "suite_test.go:3", "state": "passed", "run_time": 100000000},
+					{"id": "e2", "name": "e has a new name", "location": "suite_test.go:5", "state": "passed", "run_time": 100000000},
+					{"id": "f", "name": "f gets slower", "location": "suite_test.go:6", "state": "passed", "run_time": 500000000},
+					{"id": "g", "name": "g stays the same", "location": "suite_test.go:7", "state": "passed", "run_time": 110000000}
+				]
+			}`), 0644)).To(Succeed())
+
+			output, err := exec.Command("go", "run", "./cmd/biloba", "diff", oldFile, newFile).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			Expect(string(output)).To(Equal(`Newly failing (1):
+    failed  a starts failing  suite_test.go:1
+
+Newly passing (1):
+    passed  b starts passing  suite_test.go:2
+
+Added (1):
+    passed  c is added  suite_test.go:3
+
+Removed (1):
+    passed  d is removed  suite_test.go:4
+
+Renamed (1):
+    e is renamed  -> e has a new name  suite_test.go:5
+
+Slower (1):
+    0.500s  was 0.100s  5.00x  f gets slower  suite_test.go:6
+
+`))
+
+			output, err = exec.Command("go", "run", "./cmd/biloba", "diff", newFile, newFile).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			Expect(string(output)).To(Equal("No differences.\n"))
+		})
+
+		It("matches specs by name when a report doesn't have biloba's spec IDs", func() {
+			oldFile := filepath.Join(tempDir, "ginkgo.xml")
+			Expect(ioutil.WriteFile(oldFile, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Suite" tests="3" failures="1" errors="0" time="1">
+  <testcase name="level 1 a (starts failing)" classname="Suite" time="0.1"></testcase>
+  <testcase name="level 1 b starts passing" classname="Suite" time="0.1">
+    <failure type="Failure" message="Expected">Expected</failure>
+  </testcase>
+  <testcase name="level 1 c is removed" classname="Suite" time="0.1"></testcase>
+</testsuite>
+`), 0644)).To(Succeed())
+			newFile := filepath.Join(tempDir, "new.json")
+			Expect(ioutil.WriteFile(newFile, []byte(`{
+				"suite": "Suite",
+				"specs": [
+					{"id": "a", "name": "level 1 a \\(starts failing\\)", "component_texts": ["level 1", "a (starts failing)"], "location": "suite_test.go:1", "state": "failed", "run_time": 100000000},
+					{"id": "b", "name": "level 1 b starts passing", "component_texts": ["level 1", "b starts passing"], "location": "suite_test.go:2", "state": "passed", "run_time": 100000000},
+					{"id": "d", "name": "level 1 d is added", "component_texts": ["level 1", "d is added"], "location": "suite_test.go:4", "state": "passed", "run_time": 100000000}
+				]
+			}`), 0644)).To(Succeed())
+
+			output, err := exec.Command("go", "run", "./cmd/biloba", "diff", oldFile, newFile).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			Expect(string(output)).To(Equal(`Newly failing (1):
+    failed  level 1 a \(starts failing\)  suite_test.go:1
+
+Newly passing (1):
+    passed  level 1 b starts passing  suite_test.go:2
+
+Added (1):
+    passed  level 1 d is added  suite_test.go:4
+
+Removed (1):
+    passed  level 1 c is removed
+
+`))
+		})
+	})
 })
 
 func groupByTest(lines []testJsonEntry) [][]testJsonEntry {
@@ -443,13 +1347,36 @@ func standardizeTime(text string) string {
 	return timeRegexp.ReplaceAllString(text, "TIME")
 }
 
-func testOutputLines(testPath string) []testJsonEntry {
-	cmd := exec.Command("bash", "-c", fmt.Sprintf("BILOBA_INTEGRATION_TEST=true go test -test.v %s -args -ginkgo.noColor -ginkgo.seed 1234 | go tool test2json", testPath))
+// parallelTestOutputLines runs every parallel node of the suite, returning the
+// test2json output of node 1.
+func parallelTestOutputLines(testPath string, nodes int) []testJsonEntry {
+	args := fmt.Sprintf("-ginkgo.noColor -ginkgo.seed 1234 -ginkgo.parallel.total %d", nodes)
+	script := ""
+	for node := 2; node <= nodes; node++ {
+		script += fmt.Sprintf("(BILOBA_INTEGRATION_TEST=true go test %s -args %s -ginkgo.parallel.node %d > /dev/null &); ", testPath, args, node)
+	}
+	script += fmt.Sprintf("BILOBA_INTEGRATION_TEST=true go test -test.v %s -args %s -ginkgo.parallel.node 1 | go tool test2json", testPath, args)
+
+	return parseTestJson(exec.Command("bash", "-c", script))
+}
+
+func testOutputLines(testPath string, args ...string) []testJsonEntry {
+	return testOutputLinesWithEnv(testPath, nil, args...)
+}
+
+func testOutputLinesWithEnv(testPath string, env []string, args ...string) []testJsonEntry {
+	cmd := exec.Command("bash", "-c", fmt.Sprintf("BILOBA_INTEGRATION_TEST=true go test -test.v %s -args -ginkgo.noColor -ginkgo.seed 1234 %s | go tool test2json", testPath, strings.Join(args, " ")))
+	cmd.Env = append(os.Environ(), env...)
+
+	return parseTestJson(cmd)
+}
+
+func parseTestJson(cmd *exec.Cmd) []testJsonEntry {
 	stdOut := gbytes.NewBuffer()
 	session, err := gexec.Start(cmd, stdOut, GinkgoWriter)
 
 	Expect(err).NotTo(HaveOccurred())
-	Eventually(session, 5*time.Second).Should(gexec.Exit(0))
+	Eventually(session, 15*time.Second).Should(gexec.Exit(0))
 
 	var (
 		lines       []testJsonEntry
@@ -462,6 +1389,9 @@ func testOutputLines(testPath string) []testJsonEntry {
 		Expect(
 			json.Unmarshal([]byte(stdTime), &currentLine),
 		).To(Succeed())
+		if currentLine.Action == "start" {
+			continue
+		}
 		lines = append(lines, currentLine)
 	}
 
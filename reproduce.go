@@ -0,0 +1,114 @@
+package biloba
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// reproduceCommand builds a go test command line that reruns exactly the given
+// spec, with the same seed and randomization as this run.
+func reproduceCommand(pkg string, testFunc string, suiteDescription string, ginkgoConfig config.GinkgoConfigType, spec *types.SpecSummary) string {
+	args := []string{"go", "test", pkg}
+	if testFunc != "" {
+		args = append(args, "-run", shellQuote("^"+testFunc+"$"))
+	}
+	args = append(args,
+		"-ginkgo.focus="+shellQuote("^"+focusPattern(suiteDescription, ginkgoConfig, spec.ComponentTexts, specLocation(spec))+"$"),
+		fmt.Sprintf("-ginkgo.seed=%d", ginkgoConfig.RandomSeed),
+	)
+	if ginkgoConfig.RandomizeAllSpecs {
+		args = append(args, "-ginkgo.randomizeAllSpecs")
+	}
+	if ginkgoConfig.RegexScansFilePath {
+		args = append(args, "-ginkgo.regexScansFilePath")
+	}
+	return strings.Join(args, " ")
+}
+
+// focusPattern matches the text ginkgo applies -ginkgo.focus to: the suite
+// description and every component text (including the top level container)
+// joined with spaces, followed by the file name with -ginkgo.regexScansFilePath.
+func focusPattern(suiteDescription string, ginkgoConfig config.GinkgoConfigType, componentTexts []string, location types.CodeLocation) string {
+	text := suiteDescription + " " + strings.Join(componentTexts, " ")
+	if ginkgoConfig.RegexScansFilePath {
+		text += " " + location.FileName
+	}
+	return regexp.QuoteMeta(text)
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// currentPackage returns the import path of the package under test, based on
+// the working directory go test runs the test binary in.
+func currentPackage() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	root, module := moduleRoot(dir)
+	if root == "" {
+		return "."
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return module
+	}
+	return module + "/" + filepath.ToSlash(rel)
+}
+
+// moduleRoot walks up from dir to the directory holding go.mod, returning it
+// along with the module's name, or empty strings outside a module.
+func moduleRoot(dir string) (string, string) {
+	for root := dir; ; root = filepath.Dir(root) {
+		if module := moduleName(filepath.Join(root, "go.mod")); module != "" {
+			return root, module
+		}
+		if filepath.Dir(root) == root {
+			return "", ""
+		}
+	}
+}
+
+func moduleName(goMod string) string {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+		}
+	}
+	return ""
+}
+
+// currentTestFunc finds the go test function that is running the suite by
+// walking up the stack of the caller.
+func currentTestFunc() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		name := frame.Function[strings.LastIndex(frame.Function, ".")+1:]
+		if strings.HasPrefix(name, "Test") {
+			return name
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package biloba
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+// ResourceUsage is what a spec consumed while it ran. CPU times are those of
+// the whole test process, so they include goroutines the spec didn't start.
+type ResourceUsage struct {
+	WallTime       time.Duration `json:"wall_time"`
+	UserTime       time.Duration `json:"user_time"`
+	SystemTime     time.Duration `json:"system_time"`
+	Allocs         uint64        `json:"allocs"`
+	AllocatedBytes uint64        `json:"allocated_bytes"`
+	HeapBytes      uint64        `json:"heap_bytes"`
+}
+
+func (u ResourceUsage) String() string {
+	return fmt.Sprintf("wall %.3fs, cpu %.3fs (user %.3fs, sys %.3fs), %d allocs, %s allocated, heap %s",
+		u.WallTime.Seconds(), (u.UserTime + u.SystemTime).Seconds(), u.UserTime.Seconds(), u.SystemTime.Seconds(),
+		u.Allocs, formatBytes(u.AllocatedBytes), formatBytes(u.HeapBytes))
+}
+
+var resources = &resourceCollector{usages: map[string]*specUsage{}}
+
+// CollectResourceUsage turns on recording of the time, CPU and memory each
+// spec uses. The usage is printed in the spec's go test output and included
+// in the JSON reports. Call it before RunSpecs.
+func CollectResourceUsage() {
+	resources.mu.Lock()
+	defer resources.mu.Unlock()
+
+	resources.enabled = true
+}
+
+// resourceCollector measures specs on behalf of every reporter, so that they
+// all report the same numbers: the first reporter told that a spec will run
+// starts measuring it and the first one told that it completed stops.
+type resourceCollector struct {
+	mu      sync.Mutex
+	enabled bool
+	usages  map[string]*specUsage
+}
+
+type specUsage struct {
+	start    resourceSample
+	usage    ResourceUsage
+	finished bool
+}
+
+type resourceSample struct {
+	wall     time.Time
+	user     time.Duration
+	system   time.Duration
+	memStats runtime.MemStats
+}
+
+func takeResourceSample() resourceSample {
+	sample := resourceSample{wall: time.Now()}
+	sample.user, sample.system = processCPUTime()
+	runtime.ReadMemStats(&sample.memStats)
+	return sample
+}
+
+func (c *resourceCollector) start(spec *types.SpecSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.enabled {
+		return
+	}
+
+	key := specKey(spec)
+	if usage, ok := c.usages[key]; ok && !usage.finished {
+		return
+	}
+	c.usages[key] = &specUsage{start: takeResourceSample()}
+}
+
+// usage returns the resources used by a spec that completed, or nil when they
+// aren't collected.
+func (c *resourceCollector) usage(spec *types.SpecSummary) *ResourceUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage, ok := c.usages[specKey(spec)]
+	if !ok {
+		return nil
+	}
+	if !usage.finished {
+		end := takeResourceSample()
+		usage.usage = ResourceUsage{
+			WallTime:       end.wall.Sub(usage.start.wall),
+			UserTime:       end.user - usage.start.user,
+			SystemTime:     end.system - usage.start.system,
+			Allocs:         end.memStats.Mallocs - usage.start.memStats.Mallocs,
+			AllocatedBytes: end.memStats.TotalAlloc - usage.start.memStats.TotalAlloc,
+			HeapBytes:      end.memStats.HeapAlloc,
+		}
+		usage.finished = true
+	}
+	result := usage.usage
+	return &result
+}
+
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes) / unit
+	for _, prefix := range strings.Split("KMGTP", "") {
+		if value < unit {
+			return fmt.Sprintf("%.1f %siB", value, prefix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f EiB", value)
+}
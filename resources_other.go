@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package biloba
+
+import "time"
+
+// processCPUTime isn't available without getrusage.
+func processCPUTime() (user time.Duration, system time.Duration) {
+	return 0, 0
+}
@@ -0,0 +1,52 @@
+package biloba
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// slowestSpecsCount caps the table of slow specs printed at the end of a suite.
+const slowestSpecsCount = 10
+
+type slowSpec struct {
+	name     string
+	runTime  time.Duration
+	location types.CodeLocation
+}
+
+// slowSpecThreshold is ginkgo's -ginkgo.slowSpecThreshold, 5 seconds by default.
+func slowSpecThreshold() time.Duration {
+	return time.Duration(config.DefaultReporterConfig.SlowSpecThreshold * float64(time.Second))
+}
+
+func isSlow(spec *types.SpecSummary) bool {
+	return spec.RunTime > slowSpecThreshold()
+}
+
+// printSlowestSpecs prints the slowest of the given specs as a table, longest
+// first.
+func printSlowestSpecs(out io.Writer, specs []slowSpec) {
+	if len(specs) == 0 {
+		return
+	}
+	sorted := append([]slowSpec(nil), specs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].runTime > sorted[j].runTime
+	})
+	if len(sorted) > slowestSpecsCount {
+		sorted = sorted[:slowestSpecsCount]
+	}
+
+	fmt.Fprintf(out, "\nSlowest specs (over %s):\n", formatDuration(slowSpecThreshold()))
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, spec := range sorted {
+		fmt.Fprintf(table, "    %s\t%s\t%s\n", formatDuration(spec.runTime), spec.name, spec.location)
+	}
+	table.Flush()
+}
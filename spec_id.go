@@ -0,0 +1,48 @@
+package biloba
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+var (
+	specIDRootOnce sync.Once
+	specIDRoot     string
+)
+
+// SpecID returns a stable identifier for a spec: a hash of its component texts
+// and of the path of its file relative to the module root. Line numbers are
+// left out so that editing a file above a spec keeps its ID, and so is the
+// suite's location on disk, so IDs match across machines and checkouts.
+func SpecID(spec *types.SpecSummary) string {
+	if id, ok := replaying.specID(spec); ok {
+		return id
+	}
+	hash := sha256.New()
+	for _, text := range spec.ComponentTexts[1:] {
+		hash.Write([]byte(text))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte(relativeSpecFile(specLocation(spec).FileName)))
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+func relativeSpecFile(fileName string) string {
+	specIDRootOnce.Do(func() {
+		if dir, err := os.Getwd(); err == nil {
+			specIDRoot, _ = moduleRoot(dir)
+		}
+	})
+	if specIDRoot != "" {
+		if rel, err := filepath.Rel(specIDRoot, fileName); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(fileName)
+}
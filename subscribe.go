@@ -0,0 +1,121 @@
+package biloba
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/matt-royal/biloba/events"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+var subscribers = &subscriptions{handlers: map[int]func(events.Event){}}
+
+// Subscribe calls handler with every event of the suite, e.g. to dump the
+// state of a database when a spec fails:
+//
+//	biloba.Subscribe(func(event events.Event) {
+//		if event.Type == events.SpecDidComplete && event.Spec.HasFailureState() {
+//			dumpDatabase(event.SpecID)
+//		}
+//	})
+//
+// Events are only published when the reporter from NewSubscriptionReporter
+// is passed to ginkgo. The handler is called synchronously, before the next
+// spec starts. The returned function ends the subscription.
+func Subscribe(handler func(event events.Event)) (unsubscribe func()) {
+	return subscribers.add(handler)
+}
+
+type subscriptions struct {
+	mu       sync.Mutex
+	handlers map[int]func(events.Event)
+	nextID   int
+}
+
+func (s *subscriptions) add(handler func(events.Event)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	s.handlers[id] = handler
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.handlers, id)
+	}
+}
+
+// publish calls the handlers in the order they subscribed, without holding
+// the lock, so that handlers can unsubscribe.
+func (s *subscriptions) publish(event events.Event) {
+	event.Version = events.Version
+	s.mu.Lock()
+	ids := make([]int, 0, len(s.handlers))
+	for id := range s.handlers {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		s.mu.Lock()
+		handler, ok := s.handlers[id]
+		s.mu.Unlock()
+		if ok {
+			handler(event)
+		}
+	}
+}
+
+// NewSubscriptionReporter publishes the events of the suite to the handlers
+// registered with Subscribe.
+func NewSubscriptionReporter() *subscriptionReporter {
+	return &subscriptionReporter{&eventEmitter{emit: subscribers.publish}}
+}
+
+type subscriptionReporter struct {
+	*eventEmitter
+}
+
+// eventEmitter turns the calls ginkgo makes to its reporters into events.
+type eventEmitter struct {
+	emit func(events.Event)
+}
+
+func (e *eventEmitter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	e.emit(events.Event{
+		Type:     events.SuiteWillBegin,
+		Time:     time.Now(),
+		Config:   &config,
+		Suite:    summary,
+		Package:  suitePackage(),
+		TestFunc: suiteTestFunc(),
+	})
+}
+
+func (e *eventEmitter) BeforeSuiteDidRun(setupSummary *types.SetupSummary) {
+	e.emit(events.Event{Type: events.BeforeSuiteDidRun, Time: time.Now(), Setup: setupSummary})
+}
+
+func (e *eventEmitter) SpecWillRun(spec *types.SpecSummary) {
+	e.emit(events.Event{Type: events.SpecWillRun, Time: time.Now(), Spec: spec, SpecID: SpecID(spec)})
+}
+
+func (e *eventEmitter) SpecDidComplete(spec *types.SpecSummary) {
+	e.emit(events.Event{Type: events.SpecDidComplete, Time: time.Now(), Spec: spec, SpecID: SpecID(spec)})
+}
+
+func (e *eventEmitter) AfterSuiteDidRun(setupSummary *types.SetupSummary) {
+	e.emit(events.Event{Type: events.AfterSuiteDidRun, Time: time.Now(), Setup: setupSummary})
+}
+
+func (e *eventEmitter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	e.emit(events.Event{Type: events.SuiteDidEnd, Time: time.Now(), Suite: summary})
+}
+
+// force compatibility
+var _ ginkgo.Reporter = new(subscriptionReporter)
@@ -0,0 +1,28 @@
+package flaky_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFlaky(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	reporters := []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+	}
+	if reportFile := os.Getenv("BILOBA_REPORT_FILE"); reportFile != "" {
+		reporters = append(reporters, biloba.NewJSONReporter(reportFile))
+	}
+	if historyDir := os.Getenv("BILOBA_HISTORY_DIR"); historyDir != "" {
+		reporters = append(reporters, biloba.NewHistoryReporter(historyDir))
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Flaky Suite", reporters)
+}
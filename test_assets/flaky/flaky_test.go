@@ -0,0 +1,19 @@
+package flaky_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("level 1", func() {
+	var attempts int
+
+	It("test 1 passes on retry", func() {
+		attempts++
+		Expect(attempts).To(Equal(2))
+	})
+
+	It("test 2 passes", func() {
+		Expect(true).To(Equal(true))
+	})
+})
@@ -0,0 +1,35 @@
+package hanging_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHanging(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	if os.Getenv("BILOBA_WATCH_FOR_HANGS") != "" {
+		biloba.WatchForHangs(500*time.Millisecond, time.Second)
+	}
+	reporters := []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+	}
+	if durationsFile := os.Getenv("BILOBA_DURATIONS_FILE"); durationsFile != "" {
+		reporters = append(reporters, biloba.NewProgressReporter(durationsFile))
+	}
+	if reportFile := os.Getenv("BILOBA_REPORT_FILE"); reportFile != "" {
+		reporters = append(reporters, biloba.NewJSONReporter(reportFile))
+	}
+	if historyDir := os.Getenv("BILOBA_HISTORY_DIR"); historyDir != "" {
+		reporters = append(reporters, biloba.NewHistoryReporter(historyDir))
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Hanging Suite", reporters)
+}
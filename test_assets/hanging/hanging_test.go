@@ -0,0 +1,13 @@
+package hanging_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+)
+
+var _ = Describe("level 1", func() {
+	It("test 1 hangs", func() {
+		time.Sleep(time.Minute)
+	})
+})
@@ -0,0 +1,20 @@
+package leaky_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLeaky(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	biloba.DetectGoroutineLeaks("github.com/matt-royal/biloba/test_assets/leaky_test.ignoredWorker")
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Leaky Suite", []Reporter{biloba.NewGoTestCompatibleReporter()})
+}
@@ -0,0 +1,32 @@
+package leaky_test
+
+import (
+	"github.com/matt-royal/biloba"
+	. "github.com/onsi/ginkgo"
+)
+
+var _ = Describe("level 1", func() {
+	AfterEach(biloba.VerifyNoGoroutineLeaks)
+
+	It("test 1 leaks a goroutine", func() {
+		go leakyWorker(make(chan bool))
+	})
+
+	It("test 2 stops its goroutine", func() {
+		done := make(chan bool)
+		go leakyWorker(done)
+		close(done)
+	})
+
+	It("test 3 leaks an ignored goroutine", func() {
+		go ignoredWorker(make(chan bool))
+	})
+})
+
+func leakyWorker(done chan bool) {
+	<-done
+}
+
+func ignoredWorker(done chan bool) {
+	<-done
+}
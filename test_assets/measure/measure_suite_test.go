@@ -0,0 +1,25 @@
+package measure_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMeasure(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	reporters := []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+	}
+	if eventLog := os.Getenv("BILOBA_EVENT_LOG"); eventLog != "" {
+		reporters = append(reporters, biloba.NewEventLogReporter(eventLog))
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Measure Suite", reporters)
+}
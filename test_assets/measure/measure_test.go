@@ -0,0 +1,16 @@
+package measure_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+)
+
+var _ = Describe("level 1", func() {
+	Measure("measures x", func(b Benchmarker) {
+		b.Time("runtime", func() {
+			time.Sleep(time.Millisecond)
+		})
+		b.RecordValueWithPrecision("disk usage", 12, "MB", 1)
+	}, 3)
+})
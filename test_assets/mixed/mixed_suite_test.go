@@ -13,8 +13,22 @@ func TestMixed(t *testing.T) {
 	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
 		return
 	}
-	RegisterFailHandler(Fail)
-	RunSpecsWithDefaultAndCustomReporters(t, "Mixed Suite", []Reporter{
+	reporters := []Reporter{
 		biloba.NewGoTestCompatibleReporter(),
-	})
+	}
+	if failedSpecsFile := os.Getenv("BILOBA_FAILED_SPECS_FILE"); failedSpecsFile != "" {
+		reporters = append(reporters, biloba.NewFailedSpecsReporter(failedSpecsFile))
+	}
+	if eventLog := os.Getenv("BILOBA_EVENT_LOG"); eventLog != "" {
+		reporters = append(reporters, biloba.NewEventLogReporter(eventLog))
+	}
+	if htmlFile := os.Getenv("BILOBA_HTML_FILE"); htmlFile != "" {
+		reporters = append(reporters, biloba.NewHTMLReporter(htmlFile))
+	}
+	if markdownFile := os.Getenv("BILOBA_MARKDOWN_FILE"); markdownFile != "" {
+		reporters = append(reporters, biloba.NewMarkdownReporter(markdownFile))
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Mixed Suite", reporters)
 }
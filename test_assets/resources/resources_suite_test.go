@@ -0,0 +1,26 @@
+package resources_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestResources(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	biloba.CollectResourceUsage()
+	reporters := []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+	}
+	if reportFile := os.Getenv("BILOBA_REPORT_FILE"); reportFile != "" {
+		reporters = append(reporters, biloba.NewJSONReporter(reportFile))
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Resources Suite", reporters)
+}
@@ -0,0 +1,27 @@
+package resources_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var sink [][]byte
+
+var _ = Describe("level 1", func() {
+	It("test 1 allocates", func() {
+		for i := 0; i < 10; i++ {
+			sink = append(sink, make([]byte, 1<<20))
+		}
+		Expect(sink).To(HaveLen(10))
+	})
+
+	It("test 2 burns cpu", func() {
+		count := 0
+		for start := time.Now(); time.Since(start) < 200*time.Millisecond; {
+			count++
+		}
+		Expect(count).To(BeNumerically(">", 0))
+	})
+})
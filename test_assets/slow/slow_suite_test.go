@@ -0,0 +1,42 @@
+package slow_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSlow(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	if profileDir := os.Getenv("BILOBA_PROFILE_DIR"); profileDir != "" {
+		biloba.ProfileSlowSpecs(200*time.Millisecond, profileDir)
+	}
+	reporters := []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+	}
+	if durationsFile := os.Getenv("BILOBA_DURATIONS_FILE"); durationsFile != "" {
+		reporters = append(reporters, biloba.NewProgressReporter(durationsFile))
+	}
+	if reportFile := os.Getenv("BILOBA_REPORT_FILE"); reportFile != "" {
+		reporters = append(reporters, biloba.NewJSONReporter(reportFile))
+	}
+	if budget := os.Getenv("BILOBA_TIME_BUDGET"); budget != "" {
+		duration, err := time.ParseDuration(budget)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reporters = append(reporters, biloba.NewTimeBudgetReporter(t, duration).DescribeBudget("level 1", duration))
+	}
+	if baselineFile := os.Getenv("BILOBA_DURATION_BASELINE"); baselineFile != "" {
+		reporters = append(reporters, biloba.NewDurationRegressionReporter(baselineFile, 2, 100*time.Millisecond).FailSuite(t))
+	}
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Slow Suite", reporters)
+}
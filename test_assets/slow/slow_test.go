@@ -0,0 +1,16 @@
+package slow_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+)
+
+var _ = Describe("level 1", func() {
+	It("test 1 is slow", func() {
+		time.Sleep(300 * time.Millisecond)
+	})
+
+	It("test 2 is fast", func() {
+	})
+})
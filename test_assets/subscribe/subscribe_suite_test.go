@@ -0,0 +1,32 @@
+package subscribe_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/matt-royal/biloba"
+	"github.com/matt-royal/biloba/events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSubscribe(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	biloba.Subscribe(func(event events.Event) {
+		if event.Type == events.SpecDidComplete && event.Spec.HasFailureState() {
+			fmt.Printf("subscriber saw %s fail\n", event.Spec.ComponentTexts[len(event.Spec.ComponentTexts)-1])
+		}
+		if event.Type == events.SuiteDidEnd {
+			fmt.Printf("subscriber saw %d of %d specs pass\n", event.Suite.NumberOfPassedSpecs, event.Suite.NumberOfTotalSpecs)
+		}
+	})
+
+	RegisterFailHandler(Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "Subscribe Suite", []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+		biloba.NewSubscriptionReporter(),
+	})
+}
@@ -0,0 +1,16 @@
+package subscribe_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("level 1", func() {
+	It("test 1 fails", func() {
+		Expect(true).To(Equal(false))
+	})
+
+	It("test 2 passes", func() {
+		Expect(true).To(Equal(true))
+	})
+})
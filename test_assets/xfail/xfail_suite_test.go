@@ -0,0 +1,25 @@
+package xfail_test
+
+import (
+	"github.com/matt-royal/biloba"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestXFail(t *testing.T) {
+	if os.Getenv("BILOBA_INTEGRATION_TEST") == "" {
+		return
+	}
+	reporters := []Reporter{
+		biloba.NewGoTestCompatibleReporter(),
+	}
+	if junitFile := os.Getenv("BILOBA_JUNIT_FILE"); junitFile != "" {
+		reporters = append(reporters, biloba.NewJUnitReporter(junitFile))
+	}
+
+	RegisterFailHandler(biloba.Fail)
+	RunSpecsWithDefaultAndCustomReporters(t, "XFail Suite", reporters)
+}
@@ -0,0 +1,31 @@
+package xfail_test
+
+import (
+	"github.com/matt-royal/biloba"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("level 1", func() {
+	AfterEach(biloba.VerifyExpectedFailures)
+
+	It("test 1 fails as expected", biloba.ExpectFailure(func() {
+		Expect(true).To(Equal(false))
+	}))
+
+	It("test 2 passes unexpectedly", biloba.ExpectFailure(func() {
+		Expect(true).To(Equal(true))
+	}))
+
+	It("test 3 fails as expected [xfail]", func() {
+		Expect(true).To(Equal(false))
+	})
+
+	It("test 4 passes unexpectedly [xfail]", func() {
+		Expect(true).To(Equal(true))
+	})
+
+	It("test 5 is skipped [xfail]", func() {
+		Skip("not supported here")
+	})
+})